@@ -0,0 +1,29 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command l8inv is a command-line client for Layer 8 inventory services, built
+// with the network device types of probler, so it can query, get and write the
+// elements of network device inventories. See package cli for the commands and
+// for building a client with your own types.
+package main
+
+import (
+	"os"
+
+	"github.com/saichler/l8inventory/go/inv/cli"
+	"github.com/saichler/probler/go/types"
+)
+
+func main() {
+	os.Exit(cli.Main(os.Args[1:], &types.NetworkDevice{}, &types.NetworkDeviceList{}))
+}
@@ -0,0 +1,160 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements l8inv, a command-line client for Layer 8 inventory
+// services. It connects to a vnet as a vnic and lists services, runs GSQL
+// queries, gets elements by key, applies elements from JSON files and tails
+// change notifications.
+//
+// Elements travel as protobuf messages, so their types must be compiled into the
+// client. cmd/l8inv is built with the network device types of probler; a client
+// built without types lists services and tails notifications, and rejects the
+// commands exchanging elements. To work with your own types, build a client
+// passing them to Main:
+//
+//	func main() {
+//	    os.Exit(cli.Main(os.Args[1:], &types.NetworkDevice{}, &types.NetworkDeviceList{}))
+//	}
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultPort is the vnet port the client connects to when -port is not given.
+const DefaultPort = 26000
+
+// usage is printed for -h and on invalid arguments.
+const usage = `Usage: l8inv [flags] <command> [command flags] [args]
+
+Commands:
+  services                                   list the services and areas on the vnet
+  query  -s <service> [-a <area>] [-o table|json] "<gsql>"
+                                             run a GSQL query, e.g. "select * from Device where status=1"
+  get    -s <service> [-a <area>] -t <type> [-o table|json] <field=value>...
+                                             get the element with the given primary key
+  post|put|patch|delete -s <service> [-a <area>] -t <type> <file|->
+                                             apply the elements of a JSON file: one element,
+                                             a JSON array or JSON Lines ("-" reads stdin)
+  tail   [-s <service>] [-a <area>] [-n <target>[,<target>...]] [-na <area>] [-o json|table]
+                                             print the change notifications sent to the
+                                             notifier targets (default websock) until
+                                             interrupted, optionally of one service or area
+
+Flags:
+`
+
+// errNoTypes is returned by the commands exchanging elements when the client was
+// built without inventory types.
+var errNoTypes = errors.New("this client was built without inventory types; " +
+	"build one passing your types to cli.Main, see package cli")
+
+// usageError is an error in the command line arguments.
+type usageError struct {
+	error
+}
+
+// client holds the global settings and the connection of a command.
+type client struct {
+	port    uint
+	alias   string
+	timeout time.Duration
+	out     io.Writer
+	types   []proto.Message
+	nic     ifs.IVNic
+}
+
+// Main runs the l8inv command line with the given arguments, registering the
+// given types so their elements can be exchanged. Returns the process exit code.
+func Main(args []string, types ...proto.Message) int {
+	return Run(args, os.Stdout, types...)
+}
+
+// Run is Main printing the command output to out. Errors are printed to stderr.
+// Returns 2 for invalid arguments, detected before connecting to the vnet, 1 if
+// the command failed and 0 on success.
+func Run(args []string, out io.Writer, types ...proto.Message) int {
+	this := &client{out: out, types: types}
+	flags := flag.NewFlagSet("l8inv", flag.ContinueOnError)
+	flags.UintVar(&this.port, "port", DefaultPort, "vnet port to connect to")
+	flags.StringVar(&this.alias, "alias", "l8inv", "alias of the client vnic")
+	flags.DurationVar(&this.timeout, "timeout", 30*time.Second, "request timeout")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	command, args := flags.Arg(0), flags.Args()[1:]
+	var run func([]string) error
+	switch command {
+	case "services":
+		run = this.services
+	case "query":
+		run = this.query
+	case "get":
+		run = this.get
+	case "post":
+		run = this.write(ifs.POST)
+	case "put":
+		run = this.write(ifs.PUT)
+	case "patch":
+		run = this.write(ifs.PATCH)
+	case "delete":
+		run = this.write(ifs.DELETE)
+	case "tail":
+		run = this.tail
+	default:
+		fmt.Fprintln(os.Stderr, "unknown command", command)
+		flags.Usage()
+		return 2
+	}
+
+	err := run(args)
+	if this.nic != nil {
+		this.nic.Shutdown()
+	}
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "l8inv:", err)
+	var invalid *usageError
+	if errors.As(err, &invalid) {
+		return 2
+	}
+	return 1
+}
+
+// dial connects the client to the vnet. Commands call it once their arguments
+// are parsed and checked.
+func (this *client) dial() error {
+	nic, err := connect(this.alias, uint32(this.port), this.timeout, this.types)
+	if err != nil {
+		return err
+	}
+	this.nic = nic
+	return nil
+}
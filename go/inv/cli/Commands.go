@@ -0,0 +1,379 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/saichler/l8bus/go/overlay/health"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// healthSync is the time given to the health service to learn the vnet's
+// services after connecting.
+const healthSync = 2 * time.Second
+
+// target holds the service flags shared by the commands addressing a service.
+type target struct {
+	service  string
+	area     uint
+	typeName string
+	output   string
+}
+
+// flags creates a flag set for a command, binding the target flags. The type
+// flag is only bound if withType is set.
+func (this *target) flags(command string, withType bool) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.StringVar(&this.service, "s", "", "service name")
+	flags.UintVar(&this.area, "a", 0, "service area")
+	flags.StringVar(&this.output, "o", "table", "output format, table or json")
+	if withType {
+		flags.StringVar(&this.typeName, "t", "", "element type name, e.g. NetworkDevice")
+	}
+	return flags
+}
+
+// parse parses the command arguments and checks the required flags.
+func (this *target) parse(flags *flag.FlagSet, args []string, withType bool) error {
+	if err := flags.Parse(args); err != nil {
+		return &usageError{err}
+	}
+	if this.service == "" {
+		return invalid(flags.Name() + ": -s is required")
+	}
+	if withType && this.typeName == "" {
+		return invalid(flags.Name() + ": -t is required")
+	}
+	if this.output != "table" && this.output != "json" {
+		return invalid(flags.Name() + ": -o must be table or json")
+	}
+	return nil
+}
+
+// invalid returns an error in the command line arguments.
+func invalid(text string) error {
+	return &usageError{errors.New(text)}
+}
+
+// dialTyped connects the client to the vnet for a command exchanging elements,
+// which needs the inventory types compiled into the client.
+func (this *client) dialTyped() error {
+	if len(this.types) == 0 {
+		return errNoTypes
+	}
+	return this.dial()
+}
+
+// seconds returns the request timeout in whole seconds, at least one.
+func (this *client) seconds() int {
+	if s := int(this.timeout / time.Second); s > 0 {
+		return s
+	}
+	return 1
+}
+
+// services prints the services advertised on the vnet with their areas and the
+// aliases of the nodes running them.
+func (this *client) services(args []string) error {
+	if len(args) > 0 {
+		return invalid("services takes no arguments")
+	}
+	if err := this.dial(); err != nil {
+		return err
+	}
+	time.Sleep(healthSync)
+	nodes := make(map[string]map[int32][]string)
+	for _, hp := range health.Health(this.nic.Resources()).All() {
+		if hp.Services == nil {
+			continue
+		}
+		for name, areas := range hp.Services.ServiceToAreas {
+			if nodes[name] == nil {
+				nodes[name] = make(map[int32][]string)
+			}
+			for area := range areas.Areas {
+				nodes[name][area] = append(nodes[name][area], hp.Alias)
+			}
+		}
+	}
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := tabwriter.NewWriter(this.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tAREA\tNODES")
+	for _, name := range names {
+		areas := make([]int, 0, len(nodes[name]))
+		for area := range nodes[name] {
+			areas = append(areas, int(area))
+		}
+		sort.Ints(areas)
+		for _, area := range areas {
+			aliases := nodes[name][int32(area)]
+			sort.Strings(aliases)
+			fmt.Fprintf(w, "%s\t%d\t%s\n", name, area, strings.Join(aliases, ","))
+		}
+	}
+	return w.Flush()
+}
+
+// query runs a GSQL query against a service and prints the matching elements.
+func (this *client) query(args []string) error {
+	t := &target{}
+	flags := t.flags("query", false)
+	if err := t.parse(flags, args, false); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return invalid("query: expected one GSQL argument")
+	}
+	if err := this.dialTyped(); err != nil {
+		return err
+	}
+	elems, err := object.NewQuery(flags.Arg(0), this.nic.Resources())
+	if err != nil {
+		return err
+	}
+	resp := this.nic.ProximityRequest(t.service, byte(t.area), ifs.GET, elems.Element(), this.seconds())
+	if err := responseError(resp); err != nil {
+		return err
+	}
+	return printElements(this.out, t.output, listItems(resp.Elements()))
+}
+
+// get prints the element with the primary key given as field=value arguments.
+func (this *client) get(args []string) error {
+	t := &target{}
+	flags := t.flags("get", true)
+	if err := t.parse(flags, args, true); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return invalid("get: expected field=value arguments")
+	}
+	if err := this.dialTyped(); err != nil {
+		return err
+	}
+	elem, err := this.keyElement(t.typeName, flags.Args())
+	if err != nil {
+		return err
+	}
+	resp := this.nic.ProximityRequest(t.service, byte(t.area), ifs.GET, elem, this.seconds())
+	if err := responseError(resp); err != nil {
+		return err
+	}
+	elems := listItems(resp.Elements())
+	if len(elems) == 0 {
+		return errors.New("not found")
+	}
+	return printElements(this.out, t.output, elems)
+}
+
+// write returns the command applying the elements of a JSON file with the action.
+func (this *client) write(action ifs.Action) func([]string) error {
+	return func(args []string) error {
+		t := &target{}
+		name := strings.ToLower(actionNames[action])
+		flags := t.flags(name, true)
+		if err := t.parse(flags, args, true); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return invalid(name + ": expected one file argument")
+		}
+		if err := this.dialTyped(); err != nil {
+			return err
+		}
+		elems, err := this.readElements(t.typeName, flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		var body interface{}
+		if len(elems) == 1 {
+			body = elems[0]
+		} else if body, err = this.list(t.typeName, elems); err != nil {
+			return err
+		}
+		resp := this.nic.ProximityRequest(t.service, byte(t.area), action, body, this.seconds())
+		if err := responseError(resp); err != nil {
+			return err
+		}
+		fmt.Fprintf(this.out, "%s %d element(s)\n", actionNames[action], len(elems))
+		return nil
+	}
+}
+
+// actionNames are the names of the write actions as printed by the client.
+var actionNames = map[ifs.Action]string{ifs.POST: "POST", ifs.PUT: "PUT", ifs.PATCH: "PATCH", ifs.DELETE: "DELETE"}
+
+// newElement creates an element of the registered type.
+func (this *client) newElement(typeName string) (proto.Message, error) {
+	info, err := this.nic.Resources().Registry().Info(typeName)
+	if err != nil {
+		return nil, errors.New("type " + typeName + " is not registered in this client; " +
+			"build one passing it to cli.Main, see package cli")
+	}
+	instance, err := info.NewInstance()
+	if err != nil {
+		return nil, err
+	}
+	pb, ok := instance.(proto.Message)
+	if !ok {
+		return nil, errors.New("type " + typeName + " is not a proto message")
+	}
+	return pb, nil
+}
+
+// keyElement builds an element of the type from field=value arguments, where
+// field is a proto or JSON field name. Values are read as JSON strings, which
+// protojson also accepts for numbers and enums, except for true and false.
+func (this *client) keyElement(typeName string, args []string) (proto.Message, error) {
+	values := make(map[string]json.RawMessage, len(args))
+	quoted := make(map[string]json.RawMessage, len(args))
+	for _, arg := range args {
+		field, value, ok := strings.Cut(arg, "=")
+		if !ok || field == "" {
+			return nil, errors.New("invalid key argument " + arg + ", expected field=value")
+		}
+		quoted[field] = json.RawMessage(strconv.Quote(value))
+		values[field] = quoted[field]
+		if value == "true" || value == "false" {
+			values[field] = json.RawMessage(value)
+		}
+	}
+	elem, err := this.newElement(typeName)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := json.Marshal(values)
+	if err = protojson.Unmarshal(data, elem); err == nil {
+		return elem, nil
+	}
+	data, _ = json.Marshal(quoted)
+	if retry := protojson.Unmarshal(data, elem); retry != nil {
+		return nil, err
+	}
+	return elem, nil
+}
+
+// readElements reads elements of the type from a JSON file holding one element,
+// a JSON array of elements or JSON Lines. "-" reads stdin.
+func (this *client) readElements(typeName, path string) ([]interface{}, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raws []json.RawMessage
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			raws = append(raws, raw)
+		}
+	}
+	if len(raws) == 0 {
+		return nil, errors.New(path + " holds no elements")
+	}
+	elems := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		elem, err := this.newElement(typeName)
+		if err != nil {
+			return nil, err
+		}
+		if err := protojson.Unmarshal(raw, elem); err != nil {
+			return nil, fmt.Errorf("element %d: %s", i+1, err.Error())
+		}
+		elems[i] = elem
+	}
+	return elems, nil
+}
+
+// list wraps elements into the list type of their type, e.g. NetworkDeviceList,
+// so they are sent in a single request.
+func (this *client) list(typeName string, elems []interface{}) (interface{}, error) {
+	list, err := this.newElement(typeName + "List")
+	if err != nil {
+		return nil, err
+	}
+	field := reflect.ValueOf(list).Elem().FieldByName("List")
+	if !field.IsValid() || field.Kind() != reflect.Slice {
+		return nil, errors.New(typeName + "List has no List field")
+	}
+	for _, elem := range elems {
+		field.Set(reflect.Append(field, reflect.ValueOf(elem)))
+	}
+	return list, nil
+}
+
+// responseError returns the error carried by a response.
+func responseError(resp ifs.IElements) error {
+	if resp == nil {
+		return errors.New("no response")
+	}
+	return resp.Error()
+}
+
+// listItems expands list messages, i.e. messages with a List field, into their
+// items.
+func listItems(elems []interface{}) []interface{} {
+	var result []interface{}
+	for _, elem := range elems {
+		v := reflect.ValueOf(elem)
+		if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			if list := v.Elem().FieldByName("List"); list.IsValid() && list.Kind() == reflect.Slice {
+				for i := 0; i < list.Len(); i++ {
+					result = append(result, list.Index(i).Interface())
+				}
+				continue
+			}
+		}
+		if elem != nil {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/saichler/l8bus/go/overlay/vnic"
+	"github.com/saichler/l8reflect/go/reflect/introspecting"
+	"github.com/saichler/l8services/go/services/manager"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"github.com/saichler/l8types/go/types/l8notify"
+	"github.com/saichler/l8types/go/types/l8sysconfig"
+	"github.com/saichler/l8utils/go/utils/logger"
+	"github.com/saichler/l8utils/go/utils/registry"
+	"github.com/saichler/l8utils/go/utils/resources"
+	"google.golang.org/protobuf/proto"
+)
+
+// Queue and message limits of the client vnic.
+const (
+	maxDataSize = 1024 * 1024 * 50
+	queueSize   = 50000
+)
+
+// connect creates the client resources, registers the types and starts a vnic
+// connected to the vnet on the given port.
+func connect(alias string, port uint32, timeout time.Duration, types []proto.Message) (ifs.IVNic, error) {
+	log := logger.NewLoggerImpl(&logger.FmtLogMethod{})
+	log.SetLogLevel(ifs.Error_Level)
+	res := resources.NewResources(log)
+	res.Set(registry.NewRegistry())
+	security, err := ifs.LoadSecurityProvider()
+	if err != nil {
+		return nil, errors.New("failed to load security provider: " + err.Error())
+	}
+	res.Set(security)
+	res.Set(&l8sysconfig.L8SysConfig{
+		MaxDataSize: maxDataSize,
+		RxQueueSize: queueSize,
+		TxQueueSize: queueSize,
+		LocalAlias:  alias,
+		VnetPort:    port,
+	})
+	res.Set(introspecting.NewIntrospect(res.Registry()))
+	res.Set(manager.NewServices(res))
+	res.Registry().Register(&l8api.L8Query{})
+	res.Registry().Register(&l8notify.L8NotificationSet{})
+	for _, t := range types {
+		res.Registry().Register(t)
+	}
+
+	nic := vnic.NewVirtualNetworkInterface(res, nil)
+	nic.Start()
+	connected := make(chan struct{})
+	go func() {
+		nic.WaitForConnection()
+		close(connected)
+	}()
+	select {
+	case <-connected:
+		return nic, nil
+	case <-time.After(timeout):
+		nic.Shutdown()
+		return nil, errors.New("timed out connecting to the vnet on port " + strconv.Itoa(int(port)))
+	}
+}
@@ -0,0 +1,112 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// printElements prints elements as JSON Lines or as a table with a column per
+// top-level field. Nested values are printed in JSON.
+func printElements(out io.Writer, format string, elems []interface{}) error {
+	if format == "json" {
+		for _, elem := range elems {
+			data, err := marshal(elem)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, string(data))
+		}
+		return nil
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	rows := make([]map[string]json.RawMessage, 0, len(elems))
+	for _, elem := range elems {
+		pb, ok := elem.(proto.Message)
+		if !ok {
+			return fmt.Errorf("element of type %T is not a proto message", elem)
+		}
+		data, err := marshal(elem)
+		if err != nil {
+			return err
+		}
+		row := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(data, &row); err != nil {
+			return err
+		}
+		fields := pb.ProtoReflect().Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			name := fields.Get(i).JSONName()
+			if _, ok := row[name]; ok && !seen[name] {
+				seen[name] = true
+				columns = append(columns, name)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	cells := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			cells[i] = cell(row[column])
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "(%d element(s))\n", len(rows))
+	return nil
+}
+
+// marshal returns the protojson encoding of an element on a single line.
+func marshal(elem interface{}) ([]byte, error) {
+	pb, ok := elem.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("element of type %T is not a proto message", elem)
+	}
+	data, err := protojson.Marshal(pb)
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return data, nil
+	}
+	return compact.Bytes(), nil
+}
+
+// cell returns the table text of a JSON value: strings unquoted, other values as is.
+func cell(value json.RawMessage) string {
+	if len(value) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(value, &s) == nil {
+		return strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+	}
+	return string(value)
+}
@@ -0,0 +1,182 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// tail prints the change notifications multicast to the notifier target services
+// until interrupted, optionally only those of one inventory service or area. The client
+// registers as each target, the WebSocket notification service by default, so
+// notifications sent to other targets through NotifierConfigs can be tailed too.
+// Notifications handed to custom Notifier implementations are not seen.
+func (this *client) tail(args []string) error {
+	t := &target{}
+	flags := t.flags("tail", false)
+	flags.Lookup("o").DefValue = "json"
+	t.output = "json"
+	targets := flags.String("n", inventory.WsServiceName,
+		"comma separated notifier target services the inventories multicast notifications to")
+	targetArea := flags.Uint("na", uint(inventory.WsServiceArea), "area of the notifier target services")
+	if err := flags.Parse(args); err != nil {
+		return &usageError{err}
+	}
+	if flags.NArg() != 0 {
+		return invalid("tail takes no arguments")
+	}
+	if t.output != "table" && t.output != "json" {
+		return invalid("tail: -o must be table or json")
+	}
+	area := -1
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "a" {
+			area = int(t.area)
+		}
+	})
+	var names []string
+	for _, name := range strings.Split(*targets, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return invalid("tail: -n names no target")
+	}
+	if err := this.dial(); err != nil {
+		return err
+	}
+	mtx := &sync.Mutex{}
+	for _, name := range names {
+		printer := &notificationPrinter{client: this, service: t.service, area: area, table: t.output == "table", mtx: mtx}
+		sla := ifs.NewServiceLevelAgreement(printer, name, byte(*targetArea), false, nil)
+		if _, err := this.nic.Resources().Services().Activate(sla, this.nic); err != nil {
+			return err
+		}
+	}
+	if t.output == "table" {
+		fmt.Fprintln(this.out, "TIME\tSERVICE\tAREA\tTYPE\tKEY\tCHANGES")
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	<-interrupt
+	return nil
+}
+
+// notificationPrinter is a service handler registered as a notifier target
+// service, printing the notification sets it receives.
+type notificationPrinter struct {
+	client  *client
+	service string
+	// area is the service area printed, -1 for every area
+	area  int
+	table bool
+	mtx   *sync.Mutex
+}
+
+// Activate is a no-op; the notification set type is registered on connect.
+func (this *notificationPrinter) Activate(sla *ifs.ServiceLevelAgreement, nic ifs.IVNic) error {
+	return nil
+}
+
+// DeActivate is a no-op.
+func (this *notificationPrinter) DeActivate() error {
+	return nil
+}
+
+// print prints the notification sets carried by the elements.
+func (this *notificationPrinter) print(pb ifs.IElements) ifs.IElements {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for _, elem := range pb.Elements() {
+		n, ok := elem.(*l8notify.L8NotificationSet)
+		if !ok || (this.service != "" && n.ServiceName != this.service) ||
+			(this.area >= 0 && int(n.ServiceArea) != this.area) {
+			continue
+		}
+		if !this.table {
+			if data, err := marshal(n); err == nil {
+				fmt.Fprintln(this.client.out, string(data))
+			}
+			continue
+		}
+		changes := make([]string, 0, len(n.NotificationList))
+		for _, field := range n.NotificationList {
+			if field.PropertyId != "" {
+				changes = append(changes, field.PropertyId+"="+string(field.NewValue))
+			}
+		}
+		fmt.Fprintf(this.client.out, "%s\t%s\t%d\t%v\t%s\t%s\n",
+			time.UnixMilli(n.Time).Format(time.RFC3339), n.ServiceName, n.ServiceArea,
+			n.Type, n.ModelKey, strings.Join(changes, " "))
+	}
+	return object.New(nil, nil)
+}
+
+// Post prints POST notifications.
+func (this *notificationPrinter) Post(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.print(pb)
+}
+
+// Put prints PUT notifications.
+func (this *notificationPrinter) Put(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.print(pb)
+}
+
+// Patch prints PATCH notifications.
+func (this *notificationPrinter) Patch(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.print(pb)
+}
+
+// Delete prints DELETE notifications.
+func (this *notificationPrinter) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.print(pb)
+}
+
+// Get is not supported by the printer.
+func (this *notificationPrinter) Get(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return nil
+}
+
+// GetCopy is not supported by the printer.
+func (this *notificationPrinter) GetCopy(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return nil
+}
+
+// Failed is not supported by the printer.
+func (this *notificationPrinter) Failed(pb ifs.IElements, vnic ifs.IVNic, msg *ifs.Message) ifs.IElements {
+	return nil
+}
+
+// TransactionConfig returns nil as the printer doesn't support transactions.
+func (this *notificationPrinter) TransactionConfig() ifs.ITransactionConfig {
+	return nil
+}
+
+// WebService returns nil as the printer doesn't expose a web interface.
+func (this *notificationPrinter) WebService() ifs.IWebService {
+	return nil
+}
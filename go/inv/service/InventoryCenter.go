@@ -32,13 +32,22 @@ package inventory
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/saichler/l8services/go/services/dcache"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
+// LastAppliedAgeKey is the metadata count holding, in the results of GetCopy, the
+// time in milliseconds since the replica serving them last applied a write.
+const LastAppliedAgeKey = "LastAppliedAgeMs"
+
 // InventoryCenter is the core inventory management engine that provides distributed
 // caching capabilities for any Protocol Buffer-based data model. It wraps a Layer 8
 // distributed cache and provides CRUD operations with support for queries, metadata
@@ -63,6 +72,41 @@ type InventoryCenter struct {
 	serviceArea byte
 	// element is a prototype instance of the inventory item type
 	element interface{}
+	// mtx serializes writes so each change observes consistent before/after states
+	mtx *sync.Mutex
+	// lastSeen holds the time, in milliseconds, each cached key was last written,
+	// including writes that did not change the element
+	lastSeen map[string]int64
+	// changeCfg holds the per-write bookkeeping settings
+	changeCfg ChangeConfig
+	// keyFields holds the proto field numbers of the primary key fields, the only
+	// fields a placeholder holds
+	keyFields map[protoreflect.FieldNumber]bool
+	// placeholders is the number of cached placeholders
+	placeholders int
+	// lastApplied is the time, in milliseconds, this replica last applied a write,
+	// local or replicated from another node
+	lastApplied int64
+	// counted holds every registered metadata function, typed or not, whose counts
+	// are maintained on every write
+	counted []*typedMetadata
+	// counts holds the counts of every metadata function over the whole inventory
+	counts Facets
+	// count is the number of cached elements
+	count int
+	// stats counts the writes and queries served by this replica
+	stats *centerStats
+	// committed, if set, is called with the write lock held for the changes of
+	// every committed write, in the order they were applied
+	committed func(changes []*Change)
+	// search is the text index of the searchable fields, nil if search is not enabled
+	search *textIndex
+	// keys is the radix tree of the primary keys, built on the first prefix lookup
+	keys *keyIndex
+	// fuzzy holds the edit distance index of every field enabled for fuzzy lookup
+	fuzzy map[string]*fuzzyIndex
+	// series holds the time series of the recorded fields, nil if none are recorded
+	series *seriesStore
 }
 
 // newInventoryCenter creates a new InventoryCenter instance from the service level agreement
@@ -77,12 +121,17 @@ func newInventoryCenter(sla *ifs.ServiceLevelAgreement, vnic ifs.IVNic) *Invento
 	this.element = sla.ServiceItem()
 	this.elementType = reflect.ValueOf(this.element).Elem().Type()
 	this.resources = vnic.Resources()
+	this.mtx = &sync.Mutex{}
+	this.lastSeen = make(map[string]int64)
+	this.stats = &centerStats{}
+	this.counts = make(Facets)
 	// Preserve the FULL primary key slice. Using only PrimaryKeys()[0] caused
 	// all instances that shared the first field's value to collide in the
 	// cache (e.g. every K8s pod in cluster "Home" — primary key
 	// ("ClusterName","Key") — collapsed to a single entry because only
 	// ClusterName was registered as the key.)
 	this.primaryKeyAttributes = append([]string{}, sla.PrimaryKeys()...)
+	this.keyFields = keyFieldNumbers(this.elementType, this.primaryKeyAttributes)
 
 	fmt.Printf("[INVENTORY-PK] cache=(%s,%d) primaryKeys=%v\n",
 		this.serviceName, this.serviceArea, this.primaryKeyAttributes)
@@ -101,10 +150,10 @@ func newInventoryCenter(sla *ifs.ServiceLevelAgreement, vnic ifs.IVNic) *Invento
 //
 // This operation is idempotent - posting an element with the same primary key
 // will update the existing entry.
-func (this *InventoryCenter) Post(elements ifs.IElements) {
-	for _, element := range elements.Elements() {
-		this.elements.Post(element, elements.Notification())
-	}
+//
+// Returns one Change per element describing its effect on the cache.
+func (this *InventoryCenter) Post(elements ifs.IElements) []*Change {
+	return this.applyAll(ifs.POST, elements)
 }
 
 // Put replaces existing inventory items in the distributed cache with the provided
@@ -112,10 +161,9 @@ func (this *InventoryCenter) Post(elements ifs.IElements) {
 // Put performs a full replacement of the existing entry.
 //
 // The notification flag determines whether change notifications are propagated.
-func (this *InventoryCenter) Put(elements ifs.IElements) {
-	for _, element := range elements.Elements() {
-		this.elements.Put(element, elements.Notification())
-	}
+// Returns one Change per element describing its effect on the cache.
+func (this *InventoryCenter) Put(elements ifs.IElements) []*Change {
+	return this.applyAll(ifs.PUT, elements)
 }
 
 // Patch updates existing inventory items with partial changes. Only the non-zero
@@ -123,20 +171,29 @@ func (this *InventoryCenter) Put(elements ifs.IElements) {
 // useful for updating specific fields without replacing the entire object.
 //
 // The notification flag determines whether change notifications are propagated.
-func (this *InventoryCenter) Patch(elements ifs.IElements) {
-	for _, element := range elements.Elements() {
-		this.elements.Patch(element, elements.Notification())
-	}
+// Returns one Change per element describing its effect on the cache.
+func (this *InventoryCenter) Patch(elements ifs.IElements) []*Change {
+	return this.applyAll(ifs.PATCH, elements)
 }
 
 // Delete removes inventory items from the distributed cache. Each element in the
 // IElements collection is deleted based on its primary key.
 //
 // The notification flag determines whether deletion notifications are propagated.
-func (this *InventoryCenter) Delete(elements ifs.IElements) {
-	for _, element := range elements.Elements() {
-		this.elements.Delete(element, elements.Notification())
+// Returns one Change per element describing its effect on the cache.
+func (this *InventoryCenter) Delete(elements ifs.IElements) []*Change {
+	return this.applyAll(ifs.DELETE, elements)
+}
+
+// applyAll applies the action to every element in the collection. Elements of the
+// item list type, as received from the REST endpoints, are applied item by item.
+func (this *InventoryCenter) applyAll(action ifs.Action, elements ifs.IElements) []*Change {
+	items := this.itemsOf(elements.Elements())
+	changes := make([]*Change, 0, len(items))
+	for _, element := range items {
+		changes = append(changes, this.apply(action, element, elements.Notification()))
 	}
+	return changes
 }
 
 // Get retrieves inventory items matching the provided query. It supports pagination
@@ -145,9 +202,142 @@ func (this *InventoryCenter) Delete(elements ifs.IElements) {
 //
 // Returns:
 //   - []interface{}: Slice of matching inventory items
-//   - *l8api.L8MetaData: Metadata about the query results (total count, etc.)
+//   - *l8api.L8MetaData: Metadata about the query results (total count, etc.),
+//     including the counts of the typed metadata functions over all matches
 func (this *InventoryCenter) Get(query ifs.IQuery) ([]interface{}, *l8api.L8MetaData) {
-	return this.elements.Fetch(int(query.Page()*query.Limit()), int(query.Limit()), query)
+	return this.GetWith(query, nil)
+}
+
+// GetWith retrieves inventory items matching the provided query like Get, with
+// the given options applied to this query only.
+//
+// Example:
+//
+//	elems, metadata := center.GetWith(query, &inventory.QueryOptions{ExcludePlaceholders: true})
+func (this *InventoryCenter) GetWith(query ifs.IQuery, opts *QueryOptions) ([]interface{}, *l8api.L8MetaData) {
+	this.stats.queries.Add(1)
+	if opts.excludePlaceholders() {
+		this.mtx.Lock()
+		defer this.mtx.Unlock()
+		elems, stats := this.fetchLocked(query, opts)
+		return this.queryResult(elems, stats)
+	}
+	elems, stats := this.elements.Fetch(int(query.Page()*query.Limit()), int(query.Limit()), query)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.queryResult(elems, stats)
+}
+
+// fetchLocked fetches the page of a query. If the options exclude placeholders
+// and some are cached, the matching elements are scanned in order and the page
+// is taken from those that are not placeholders, which the Total count of the
+// metadata is set to. Must be called with the write lock held.
+func (this *InventoryCenter) fetchLocked(query ifs.IQuery, opts *QueryOptions) ([]interface{}, *l8api.L8MetaData) {
+	offset, limit := int(query.Page()*query.Limit()), int(query.Limit())
+	if !opts.excludePlaceholders() || this.placeholders == 0 {
+		return this.elements.Fetch(offset, limit, query)
+	}
+	var page []interface{}
+	var stats *l8api.L8MetaData
+	total := 0
+	for start := 0; ; start += exportBlock {
+		elems, blockStats := this.elements.Fetch(start, exportBlock, query)
+		if stats == nil {
+			stats = blockStats
+		}
+		for _, elem := range elems {
+			if this.isPlaceholder(elem) {
+				continue
+			}
+			if total >= offset && (limit <= 0 || total < offset+limit) {
+				page = append(page, elem)
+			}
+			total++
+		}
+		if len(elems) < exportBlock {
+			break
+		}
+	}
+	result := &l8api.L8MetaData{}
+	if stats != nil {
+		result = proto.Clone(stats).(*l8api.L8MetaData)
+	}
+	if result.KeyCount == nil {
+		result.KeyCount = &l8api.L8Count{}
+	}
+	if result.KeyCount.Counts == nil {
+		result.KeyCount.Counts = make(map[string]int32)
+	}
+	result.KeyCount.Counts["Total"] = int32(total)
+	return page, result
+}
+
+// queryResult completes the fetched page of a query by adding the metadata counts
+// maintained over the whole inventory to a copy of the metadata, which the cache
+// may share between queries. Must be called with the write lock held.
+func (this *InventoryCenter) queryResult(elems []interface{}, stats *l8api.L8MetaData) ([]interface{}, *l8api.L8MetaData) {
+	if len(this.counts) > 0 {
+		if stats != nil {
+			stats = proto.Clone(stats).(*l8api.L8MetaData)
+		}
+		stats = this.counts.metaData(stats)
+	}
+	return elems, stats
+}
+
+// GetCopy retrieves deep copies of the inventory items matching the query from the
+// local replica, so callers may modify them freely, with the given options, nil
+// for the defaults. The page is fetched and copied under the write lock, so no
+// write is applied in between. Along with the results it returns the time, in
+// milliseconds, the replica last applied a write, which indicates how fresh the
+// copies are; the metadata carries it as the age under LastAppliedAgeKey.
+func (this *InventoryCenter) GetCopy(query ifs.IQuery, opts *QueryOptions) ([]interface{}, *l8api.L8MetaData, int64) {
+	this.stats.queries.Add(1)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	elems, stats := this.fetchLocked(query, opts)
+	elems, stats = this.queryResult(elems, stats)
+	return copyElements(elems), this.freshness(stats), this.lastApplied
+}
+
+// copies returns deep copies of the elements taken under the write lock, so no
+// write is applied to them while they are copied, together with a copy of the
+// metadata holding the replica's freshness under LastAppliedAgeKey.
+func (this *InventoryCenter) copies(elems []interface{}, stats *l8api.L8MetaData) ([]interface{}, *l8api.L8MetaData) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return copyElements(elems), this.freshness(stats)
+}
+
+// freshness returns a copy of the metadata with the time since this replica last
+// applied a write, in milliseconds, under LastAppliedAgeKey. The age is capped at
+// math.MaxInt32 and is -1 if the replica never applied a write. Must be called
+// with the write lock held.
+func (this *InventoryCenter) freshness(stats *l8api.L8MetaData) *l8api.L8MetaData {
+	result := &l8api.L8MetaData{}
+	if stats != nil {
+		result = proto.Clone(stats).(*l8api.L8MetaData)
+	}
+	if result.KeyCount == nil {
+		result.KeyCount = &l8api.L8Count{}
+	}
+	if result.KeyCount.Counts == nil {
+		result.KeyCount.Counts = make(map[string]int32)
+	}
+	age := int64(-1)
+	if this.lastApplied > 0 {
+		age = min(max(time.Now().UnixMilli()-this.lastApplied, 0), math.MaxInt32)
+	}
+	result.KeyCount.Counts[LastAppliedAgeKey] = int32(age)
+	return result
+}
+
+// LastApplied returns the time, in milliseconds, this replica last applied a write,
+// local or replicated from another node. Returns 0 if it never applied one.
+func (this *InventoryCenter) LastApplied() int64 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.lastApplied
 }
 
 // ElementByElement retrieves a single inventory item by matching its primary key.
@@ -159,9 +349,29 @@ func (this *InventoryCenter) ElementByElement(elem interface{}) interface{} {
 	return resp
 }
 
-// AddMetadata registers a custom metadata function that will be called for each
-// element during query operations. The function receives an element and should
-// return (true, value) if it produces metadata, or (false, "") otherwise.
+// copyOf returns a deep copy of a cached element, taken under the write lock so
+// no write changes the element while it is copied.
+func (this *InventoryCenter) copyOf(elem interface{}) interface{} {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return cloneElement(elem)
+}
+
+// LastSeen returns the time, in milliseconds, the element with the same primary key
+// was last written, including writes that left it unchanged and were therefore not
+// forwarded or notified. Returns 0 if the key was never written or was deleted,
+// or if ChangeConfig.SkipLastSeen is set.
+func (this *InventoryCenter) LastSeen(elem interface{}) int64 {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.lastSeen[this.keyOf(elem)]
+}
+
+// AddMetadata registers a custom metadata function, called for an element on every
+// write of it. The function receives an element and should return (true, value)
+// if it produces metadata, or (false, "") otherwise. The counts per value over the
+// whole inventory are maintained on every write, added to the metadata returned
+// by Get as described by Facets and available through Metadata.
 //
 // Example:
 //
@@ -172,7 +382,14 @@ func (this *InventoryCenter) ElementByElement(elem interface{}) interface{} {
 //	    return false, ""
 //	})
 func (this *InventoryCenter) AddMetadata(name string, f func(interface{}) (bool, string)) {
-	this.elements.AddMetadataFunc(name, f)
+	this.mtx.Lock()
+	this.countMetadata(name, func(elem interface{}) []*Facet {
+		if ok, value := f(elem); ok {
+			return []*Facet{{Name: value}}
+		}
+		return nil
+	})
+	this.mtx.Unlock()
 }
 
 // Inventory retrieves the InventoryCenter for a registered inventory service.
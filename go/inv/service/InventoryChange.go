@@ -0,0 +1,227 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/proto"
+)
+
+// Change describes the effect of a single write on a cached inventory element.
+// InventoryCenter returns one Change per element it applies, which InventoryService
+// uses to decide what is forwarded downstream and notified.
+type Change struct {
+	// Action is the operation that was applied
+	Action ifs.Action
+	// Key is the composite primary key of the element
+	Key string
+	// Element is the element as it was received
+	Element interface{}
+	// Existed reports whether the element was cached before the write
+	Existed bool
+	// Old is a deep copy of the cached element before the write, nil if it did not
+	// exist or the copy was skipped through ChangeConfig.SkipCompare
+	Old interface{}
+	// New is the cached element after the write, nil if it no longer exists.
+	// It is shared with the cache and must not be modified.
+	New interface{}
+	// Fields lists the fields that differ between Old and New, computed for
+	// Put and Patch of an existing element
+	Fields []*FieldChange
+	// Err is the error the cache returned for the write, nil if it succeeded.
+	// A failed write is assumed to have left the element unchanged.
+	Err error
+	// unrecord undoes the series samples recorded for the write, nil if none
+	unrecord func()
+}
+
+// ChangeConfig controls the bookkeeping an inventory does on every write. A
+// *ChangeConfig can be passed as an SLA argument.
+//
+// Example, for a write-heavy inventory whose pollers rarely re-send unchanged state:
+//
+//	sla.SetArgs(linksId, &inventory.ChangeConfig{SkipCompare: true, SkipLastSeen: true})
+type ChangeConfig struct {
+	// SkipCompare stops writes from deep-copying the cached element, under the
+	// write lock, before they are applied. Without the copy, writes that leave an
+	// element unchanged are forwarded and notified, patches are forwarded as
+	// received instead of reduced to their changed fields, and notifications carry
+	// no field changes. Transactions and metadata counts still copy the elements
+	// they need.
+	SkipCompare bool
+	// SkipLastSeen stops recording the time each key was last written, so
+	// LastSeen always returns 0.
+	SkipLastSeen bool
+}
+
+// SetChangeConfig replaces the per-write bookkeeping settings. A nil config
+// restores the defaults, comparing every write and recording last-seen times.
+func (this *InventoryCenter) SetChangeConfig(cfg *ChangeConfig) {
+	if cfg == nil {
+		cfg = &ChangeConfig{}
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.changeCfg = *cfg
+	if cfg.SkipLastSeen {
+		this.lastSeen = make(map[string]int64)
+	}
+}
+
+// NoOp reports whether the write left the cache unchanged, i.e. the element is
+// byte-identical before and after a Post, Put or Patch, or a Delete removed an
+// element that did not exist. Failed writes are no-ops; writes whose Old copy was
+// skipped never are.
+func (this *Change) NoOp() bool {
+	if this.Err != nil {
+		return true
+	}
+	if this.Action == ifs.DELETE {
+		return !this.Existed
+	}
+	if this.Old == nil || this.New == nil {
+		return false
+	}
+	return equalElements(this.Old, this.New)
+}
+
+// equalElements reports whether two elements have the same deterministic
+// serialization.
+func equalElements(a, b interface{}) bool {
+	pa, ok := a.(proto.Message)
+	if !ok {
+		return false
+	}
+	pb, ok := b.(proto.Message)
+	if !ok {
+		return false
+	}
+	opts := proto.MarshalOptions{Deterministic: true}
+	da, err := opts.Marshal(pa)
+	if err != nil {
+		return false
+	}
+	db, err := opts.Marshal(pb)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(da, db)
+}
+
+// apply performs a single write on the distributed cache and records its effect.
+// Writes are serialized so the before and after states belong to the same change.
+// The last-seen time of the key is refreshed even if the write was a no-op. A
+// write the cache failed is logged and returned as a no-op change holding the error.
+func (this *InventoryCenter) apply(action ifs.Action, element interface{}, notification bool) *Change {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	change, err := this.applyLocked(action, element, notification, false)
+	if err != nil {
+		this.resources.Logger().Error("Failed to apply write to ", this.serviceName, ": ", err.Error())
+	}
+	this.recordWrites(change)
+	return change
+}
+
+// applyLocked performs a single write and records its effect. The cached element
+// is copied into Change.Old unless ChangeConfig.SkipCompare is set; keepOld forces
+// the copy. If the cache fails the write, the change holds the error and the
+// current element in New, and no bookkeeping is updated. The write statistics and
+// last-applied and last-seen times are left to recordWrites, so a transaction
+// records them only once it commits. Must be called with the write lock held.
+func (this *InventoryCenter) applyLocked(action ifs.Action, element interface{}, notification, keepOld bool) (*Change, error) {
+	change := &Change{Action: action, Key: this.keyOf(element), Element: element}
+	placeholder := false
+	if cached := this.ElementByElement(element); cached != nil {
+		change.Existed = true
+		placeholder = this.isPlaceholder(cached)
+		if keepOld || !this.changeCfg.SkipCompare || len(this.counted) > 0 {
+			change.Old = cloneElement(cached)
+		}
+	}
+	var err error
+	switch action {
+	case ifs.POST:
+		if placeholder {
+			// a real Post replaces the placeholder as a whole
+			_, err = this.elements.Put(element, notification)
+			break
+		}
+		_, err = this.elements.Post(element, notification)
+	case ifs.PUT:
+		_, err = this.elements.Put(element, notification)
+	case ifs.PATCH:
+		_, err = this.elements.Patch(element, notification)
+	case ifs.DELETE:
+		_, err = this.elements.Delete(element, notification)
+	}
+	if err != nil {
+		change.Err = err
+		change.New = this.ElementByElement(element)
+		return change, err
+	}
+	now := time.Now().UnixMilli()
+	if action == ifs.DELETE {
+		this.countChange(change.Existed, change.Old, nil)
+		this.countPlaceholder(placeholder, nil)
+		this.indexElement(change.Key, nil)
+		change.unrecord = this.recordSeries(change.Key, nil, now)
+		return change, nil
+	}
+	change.New = this.ElementByElement(element)
+	this.countChange(change.Existed, change.Old, change.New)
+	this.countPlaceholder(placeholder, change.New)
+	this.indexElement(change.Key, change.New)
+	change.unrecord = this.recordSeries(change.Key, change.New, now)
+	if (action == ifs.PUT || action == ifs.PATCH) && change.Old != nil && change.New != nil {
+		change.Fields = diffElements(change.Old, change.New)
+	}
+	return change, nil
+}
+
+// recordWrites counts the successful writes among the changes, refreshes the
+// last-applied time and the last-seen times of their keys and hands the changes
+// to the committed function. Must be called with the write lock held.
+func (this *InventoryCenter) recordWrites(changes ...*Change) {
+	now := time.Now().UnixMilli()
+	for _, change := range changes {
+		if change.Err != nil {
+			continue
+		}
+		this.stats.mutated(change.Action)
+		this.lastApplied = now
+		if change.Action == ifs.DELETE {
+			delete(this.lastSeen, change.Key)
+		} else if change.New != nil && !this.changeCfg.SkipLastSeen {
+			this.lastSeen[change.Key] = now
+		}
+	}
+	if this.committed != nil {
+		this.committed(changes)
+	}
+}
+
+// changedElements returns the elements of the changes that modified the cache.
+func changedElements(changes []*Change) []interface{} {
+	result := make([]interface{}, 0, len(changes))
+	for _, change := range changes {
+		if !change.NoOp() {
+			result = append(result, change.Element)
+		}
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// BulkModelKey is the ModelKey of the "bulk changed" notification sent when the
+// notification rate cap is exceeded. Clients receiving it should re-query the
+// inventory instead of applying individual updates.
+const BulkModelKey = "*"
+
+// notifyLimiter debounces and rate limits the notifications of an inventory.
+// Changes to the same key within the debounce window are merged into one, and
+// notifications beyond the per-second cap are replaced by a single bulk event.
+type notifyLimiter struct {
+	mtx *sync.Mutex
+	// pending holds the merged change per key waiting for the window to end
+	pending map[string]*Change
+	// order holds the pending keys in the order they were first changed
+	order []string
+	// timer ends the current debounce window, nil if none is running
+	timer *time.Timer
+	// second is the unix second the count belongs to
+	second int64
+	// count is the number of notifications sent in the current second
+	count int
+	// bulkSent is set once the bulk event of the current second was sent
+	bulkSent bool
+	// config returns the notification settings in effect
+	config func() *NotifyConfig
+	// send delivers notifications of changes to the notifiers
+	send func([]*Change)
+	// bulk delivers the bulk event to the notifiers
+	bulk func()
+	// copy returns a deep copy of a cached element, used to keep the state of a
+	// queued change from being altered by later writes to the same element
+	copy func(interface{}) interface{}
+}
+
+// newNotifyLimiter creates a limiter delivering through send and bulk. Changes
+// held for a debounce window keep a copy of their element taken with copy.
+func newNotifyLimiter(config func() *NotifyConfig, send func([]*Change), bulk func(),
+	copy func(interface{}) interface{}) *notifyLimiter {
+	return &notifyLimiter{mtx: &sync.Mutex{}, pending: make(map[string]*Change),
+		config: config, send: send, bulk: bulk, copy: copy}
+}
+
+// add hands the changes to the limiter. Without a debounce window they are
+// released right away, subject to the rate cap.
+func (this *notifyLimiter) add(changes []*Change) {
+	cfg := this.config()
+	if cfg.Debounce <= 0 && cfg.MaxPerSecond <= 0 {
+		this.send(changes)
+		return
+	}
+	if cfg.Debounce <= 0 {
+		this.release(changes, cfg)
+		return
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for _, change := range changes {
+		if change.NoOp() {
+			continue
+		}
+		change = this.queued(change)
+		prev, ok := this.pending[change.Key]
+		if !ok {
+			this.pending[change.Key] = change
+			this.order = append(this.order, change.Key)
+			continue
+		}
+		if merged := mergeChanges(prev, change); merged != nil {
+			this.pending[change.Key] = merged
+		} else {
+			delete(this.pending, change.Key)
+		}
+	}
+	if this.timer == nil && len(this.order) > 0 {
+		this.timer = time.AfterFunc(cfg.Debounce, this.flush)
+	}
+}
+
+// queued returns the change to hold for the debounce window: a shallow copy
+// whose New is a deep copy of the cached element, as the cache keeps updating the
+// element it holds while the change waits.
+func (this *notifyLimiter) queued(change *Change) *Change {
+	queued := *change
+	if queued.New != nil {
+		queued.New = this.copy(queued.New)
+	}
+	return &queued
+}
+
+// flush ends the debounce window, releasing the pending changes.
+func (this *notifyLimiter) flush() {
+	this.mtx.Lock()
+	changes := make([]*Change, 0, len(this.pending))
+	for _, key := range this.order {
+		if change, ok := this.pending[key]; ok {
+			changes = append(changes, change)
+			delete(this.pending, key)
+		}
+	}
+	this.order = nil
+	if this.timer != nil {
+		this.timer.Stop()
+		this.timer = nil
+	}
+	this.mtx.Unlock()
+	if len(changes) > 0 {
+		this.release(changes, this.config())
+	}
+}
+
+// release sends the changes allowed by the rate cap. The first change over the
+// cap in a second triggers the bulk event, the rest of that second is dropped.
+func (this *notifyLimiter) release(changes []*Change, cfg *NotifyConfig) {
+	if cfg.MaxPerSecond <= 0 {
+		this.send(changes)
+		return
+	}
+	this.mtx.Lock()
+	now := time.Now().Unix()
+	if now != this.second {
+		this.second = now
+		this.count = 0
+		this.bulkSent = false
+	}
+	allowed := make([]*Change, 0, len(changes))
+	bulk := false
+	for _, change := range changes {
+		if change.NoOp() {
+			continue
+		}
+		if this.count < cfg.MaxPerSecond {
+			this.count++
+			allowed = append(allowed, change)
+		} else if !this.bulkSent {
+			this.bulkSent = true
+			bulk = true
+		}
+	}
+	this.mtx.Unlock()
+	this.send(allowed)
+	if bulk {
+		this.bulk()
+	}
+}
+
+// mergeChanges merges two successive changes of the same key into one change
+// from the state before the first to the state after the second. Returns nil if
+// the changes cancel out, e.g. a Post followed by a Delete.
+func mergeChanges(first, second *Change) *Change {
+	merged := &Change{Key: second.Key, Element: second.Element, Existed: first.Existed, Old: first.Old, New: second.New}
+	switch {
+	case second.Action == ifs.DELETE:
+		merged.Action = ifs.DELETE
+	case !first.Existed:
+		merged.Action = ifs.POST
+	case first.Action == ifs.PATCH && second.Action == ifs.PATCH:
+		merged.Action = ifs.PATCH
+	default:
+		merged.Action = ifs.PUT
+	}
+	if merged.NoOp() {
+		return nil
+	}
+	if merged.Action != ifs.POST && merged.Action != ifs.DELETE && merged.Old != nil {
+		merged.Fields = diffElements(merged.Old, merged.New)
+	}
+	return merged
+}
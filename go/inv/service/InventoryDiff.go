@@ -0,0 +1,145 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldChange describes a single field whose value differs between the previous
+// and the new version of a cached element. Nested messages are compared field by
+// field, so Path points at the innermost changed field (e.g. "status.state");
+// repeated and map fields are compared as a whole.
+type FieldChange struct {
+	// Path is the dot separated path of proto field names
+	Path string
+	// Old is the previous value, invalid if the field was not set
+	Old protoreflect.Value
+	// New is the new value, invalid if the field is no longer set
+	New protoreflect.Value
+	// top is the top level field of the element containing the change
+	top protoreflect.FieldDescriptor
+	// field is the changed field itself
+	field protoreflect.FieldDescriptor
+	// parent is the type of the message holding field
+	parent protoreflect.MessageType
+}
+
+// diffElements returns the fields that differ between two versions of an element,
+// or nil if either is not a proto message.
+func diffElements(old, new interface{}) []*FieldChange {
+	pold, ok := old.(proto.Message)
+	if !ok {
+		return nil
+	}
+	pnew, ok := new.(proto.Message)
+	if !ok {
+		return nil
+	}
+	var changes []*FieldChange
+	diffMessages("", nil, pold.ProtoReflect(), pnew.ProtoReflect(), &changes)
+	return changes
+}
+
+// diffMessages appends the differences between two messages of the same type.
+func diffMessages(prefix string, top protoreflect.FieldDescriptor, a, b protoreflect.Message, changes *[]*FieldChange) {
+	fields := b.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		hasA, hasB := a.Has(fd), b.Has(fd)
+		if !hasA && !hasB {
+			continue
+		}
+		path := string(fd.Name())
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		fieldTop := top
+		if fieldTop == nil {
+			fieldTop = fd
+		}
+		if hasA && hasB && fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+			diffMessages(path, fieldTop, a.Get(fd).Message(), b.Get(fd).Message(), changes)
+			continue
+		}
+		if hasA && hasB && equalField(fd, a, b) {
+			continue
+		}
+		change := &FieldChange{Path: path, top: fieldTop, field: fd, parent: b.Type()}
+		if hasA {
+			change.Old = a.Get(fd)
+		}
+		if hasB {
+			change.New = b.Get(fd)
+		}
+		*changes = append(*changes, change)
+	}
+}
+
+// equalField reports whether a field, set on both messages, holds the same value.
+// Scalars are compared directly, repeated and map fields by their deterministic
+// serialization.
+func equalField(fd protoreflect.FieldDescriptor, a, b protoreflect.Message) bool {
+	if fd.IsList() || fd.IsMap() {
+		return bytes.Equal(fieldBytes(fd, a), fieldBytes(fd, b))
+	}
+	if fd.Kind() == protoreflect.BytesKind {
+		return bytes.Equal(a.Get(fd).Bytes(), b.Get(fd).Bytes())
+	}
+	return a.Get(fd).Interface() == b.Get(fd).Interface()
+}
+
+// fieldBytes serializes a message holding only the given field of m.
+func fieldBytes(fd protoreflect.FieldDescriptor, m protoreflect.Message) []byte {
+	tmp := m.Type().New()
+	tmp.Set(fd, m.Get(fd))
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(tmp.Interface())
+	return data
+}
+
+// delta builds a copy of the change's new element holding only its primary key
+// fields and the top level fields that changed, suitable for forwarding as a PATCH.
+// Returns false if the delta cannot be expressed as a PATCH, i.e. when the change
+// has no field diff or a field was cleared, as PATCH only merges set fields.
+func (this *InventoryCenter) delta(change *Change) (interface{}, bool) {
+	if len(change.Fields) == 0 {
+		return nil, false
+	}
+	pnew, ok := change.New.(proto.Message)
+	if !ok {
+		return nil, false
+	}
+	src := pnew.ProtoReflect()
+	dst := src.Type().New()
+	for _, field := range change.Fields {
+		if !field.New.IsValid() || !src.Has(field.top) {
+			return nil, false
+		}
+		dst.Set(field.top, src.Get(field.top))
+	}
+	result := proto.Clone(dst.Interface())
+	from := reflect.ValueOf(pnew).Elem()
+	to := reflect.ValueOf(result).Elem()
+	for _, attr := range this.primaryKeyAttributes {
+		f := to.FieldByName(attr)
+		if f.IsValid() && f.CanSet() {
+			f.Set(from.FieldByName(attr))
+		}
+	}
+	return result, true
+}
@@ -0,0 +1,307 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Format is a file format of bulk exports and imports.
+type Format int
+
+const (
+	// JSONLines writes one protojson element per line
+	JSONLines Format = iota
+	// CSV writes one row per element, with a header row of the column paths
+	CSV
+	// ProtoDelimited writes each element as a varint length followed by its
+	// protobuf encoding, as read by protodelim
+	ProtoDelimited
+)
+
+// exportBlock is the number of elements fetched from the cache at a time.
+const exportBlock = 1000
+
+// FormatOf returns the format of a file by its extension: ".jsonl", ".ndjson" and
+// ".json" for JSONLines, ".csv" for CSV, and ".pb", ".bin" and ".protodelim" for
+// ProtoDelimited.
+func FormatOf(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson", ".json":
+		return JSONLines, nil
+	case ".csv":
+		return CSV, nil
+	case ".pb", ".bin", ".protodelim":
+		return ProtoDelimited, nil
+	}
+	return 0, errors.New("unknown file format of " + path)
+}
+
+// ExportConfig configures a bulk export.
+type ExportConfig struct {
+	// Format is the output format
+	Format Format
+	// Query selects the exported elements, nil for all elements
+	Query *l8api.L8Query
+	// Columns are the dot separated field paths written as CSV columns, e.g.
+	// "id" or "location.site", by proto or JSON field name. Empty for every
+	// scalar field, with nested messages flattened. Repeated, map and message
+	// fields are written in JSON.
+	Columns []string
+	// ExcludePlaceholders leaves placeholders out of the export
+	ExcludePlaceholders bool
+}
+
+// Export writes the inventory elements selected by the config to w and returns
+// the number of elements written. The matching elements are selected in one pass
+// under the write lock, then copied in blocks, so writes to the inventory are only
+// held up while they are selected or a block is copied. Every element selected is
+// exported once, as it was when its block was copied; elements deleted or no
+// longer matching by then are skipped, and elements added during the export are
+// not included.
+//
+// Example:
+//
+//	n, err := center.Export(os.Stdout, &inventory.ExportConfig{Format: inventory.CSV,
+//	    Columns: []string{"id", "status", "location.site"}})
+func (this *InventoryCenter) Export(w io.Writer, cfg *ExportConfig) (int, error) {
+	if cfg == nil {
+		cfg = &ExportConfig{}
+	}
+	query, err := this.exportQuery(cfg.Query)
+	if err != nil {
+		return 0, err
+	}
+	buff := bufio.NewWriter(w)
+	var write func(proto.Message) error
+	flush := buff.Flush
+	switch cfg.Format {
+	case JSONLines:
+		write = func(pb proto.Message) error {
+			data, err := protojson.Marshal(pb)
+			if err != nil {
+				return err
+			}
+			buff.Write(data)
+			return buff.WriteByte('\n')
+		}
+	case ProtoDelimited:
+		write = func(pb proto.Message) error {
+			_, err := protodelim.MarshalTo(buff, pb)
+			return err
+		}
+	case CSV:
+		md := this.element.(proto.Message).ProtoReflect().Descriptor()
+		columns := cfg.Columns
+		if len(columns) == 0 {
+			columns = csvColumns(md, "", map[protoreflect.FullName]bool{})
+		}
+		paths := make([][]protoreflect.FieldDescriptor, len(columns))
+		for i, column := range columns {
+			if paths[i], err = fieldPath(md, column); err != nil {
+				return 0, err
+			}
+		}
+		cw := csv.NewWriter(buff)
+		if err := cw.Write(columns); err != nil {
+			return 0, err
+		}
+		row := make([]string, len(columns))
+		write = func(pb proto.Message) error {
+			for i, path := range paths {
+				row[i] = csvValue(pb.ProtoReflect(), path)
+			}
+			return cw.Write(row)
+		}
+		flush = func() error {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			return buff.Flush()
+		}
+	default:
+		return 0, fmt.Errorf("unsupported export format %d", cfg.Format)
+	}
+
+	opts := &QueryOptions{ExcludePlaceholders: cfg.ExcludePlaceholders}
+	this.mtx.Lock()
+	selected := this.selectLocked(query, opts)
+	this.mtx.Unlock()
+	count := 0
+	for start := 0; start < len(selected); start += exportBlock {
+		end := start + exportBlock
+		if end > len(selected) {
+			end = len(selected)
+		}
+		this.mtx.Lock()
+		elems := make([]interface{}, 0, end-start)
+		for _, elem := range selected[start:end] {
+			cached := this.ElementByElement(elem)
+			if cached == nil || !query.Match(cached) || (opts.excludePlaceholders() && this.isPlaceholder(cached)) {
+				continue
+			}
+			elems = append(elems, cloneElement(cached))
+		}
+		this.mtx.Unlock()
+		for _, elem := range elems {
+			pb, ok := elem.(proto.Message)
+			if !ok {
+				return count, fmt.Errorf("element of type %T is not a proto message", elem)
+			}
+			if err := write(pb); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, flush()
+}
+
+// selectLocked returns the cached elements matching the query, collected in one
+// pass so that writes made while they are exported cannot shift the pages of the
+// cache and skip or repeat elements. Must be called with the write lock held.
+func (this *InventoryCenter) selectLocked(query ifs.IQuery, opts *QueryOptions) []interface{} {
+	var selected []interface{}
+	for start := 0; ; start += exportBlock {
+		elems, _ := this.elements.Fetch(start, exportBlock, query)
+		selected = append(selected, this.withoutPlaceholders(elems, opts)...)
+		if len(elems) < exportBlock {
+			return selected
+		}
+	}
+}
+
+// ExportFile writes the selected elements to a file, replacing it if it exists.
+// Returns the number of elements written.
+func (this *InventoryCenter) ExportFile(path string, cfg *ExportConfig) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	count, err := this.Export(file, cfg)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return count, err
+}
+
+// exportQuery converts the export query, or builds one selecting every element.
+func (this *InventoryCenter) exportQuery(pquery *l8api.L8Query) (ifs.IQuery, error) {
+	if pquery != nil {
+		return object.New(nil, pquery).Query(this.resources)
+	}
+	elems, err := object.NewQuery("select * from "+this.elementType.Name(), this.resources)
+	if err != nil {
+		return nil, err
+	}
+	return elems.Query(this.resources)
+}
+
+// csvColumns returns the paths of every field of a message, flattening singular
+// nested messages into their fields. A message nested in itself is written as a
+// single JSON column rather than flattened again.
+func csvColumns(md protoreflect.MessageDescriptor, prefix string, seen map[protoreflect.FullName]bool) []string {
+	seen[md.FullName()] = true
+	defer delete(seen, md.FullName())
+	var columns []string
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !seen[fd.Message().FullName()] {
+			columns = append(columns, csvColumns(fd.Message(), path+".", seen)...)
+			continue
+		}
+		columns = append(columns, path)
+	}
+	return columns
+}
+
+// fieldPath resolves a dot separated path of proto or JSON field names.
+func fieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	names := strings.Split(path, ".")
+	result := make([]protoreflect.FieldDescriptor, 0, len(names))
+	for i, name := range names {
+		if md == nil {
+			return nil, errors.New("field path " + path + " continues past a scalar field")
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, errors.New("unknown field " + name + " in " + string(md.FullName()))
+		}
+		result = append(result, fd)
+		md = nil
+		if i < len(names)-1 && fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+			md = fd.Message()
+		}
+	}
+	return result, nil
+}
+
+// csvValue returns the text of the field at the path. Returns "" if a message on
+// the path, or the field itself when it tracks presence, is not set.
+func csvValue(m protoreflect.Message, path []protoreflect.FieldDescriptor) string {
+	for _, fd := range path[:len(path)-1] {
+		if !m.Has(fd) {
+			return ""
+		}
+		m = m.Get(fd).Message()
+	}
+	fd := path[len(path)-1]
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil {
+		if !m.Has(fd) {
+			return ""
+		}
+		return string(fieldJSON(m.Type(), fd, m.Get(fd)))
+	}
+	if fd.HasPresence() && !m.Has(fd) {
+		return ""
+	}
+	value := m.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(value.Enum()))
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(value.Bytes())
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	}
+	return value.String()
+}
@@ -0,0 +1,387 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// Facet is a bucket a typed metadata function puts an element in, with a numeric
+// value summed per bucket and optional nested buckets.
+type Facet struct {
+	// Name is the bucket, e.g. the vendor "Cisco". An empty name adds Value to
+	// the metadata's total without a bucket, e.g. for a total port count.
+	Name string
+	// Value is added to the sum of the bucket
+	Value float64
+	// Facets are named buckets nested in this one, e.g. the models of a vendor
+	Facets []*Facet
+}
+
+// MetadataFunc is a typed metadata function. It returns the buckets an element
+// falls in, none if the element does not contribute to the metadata. An element
+// returning several facets with the same name is counted once in that bucket.
+//
+// Example, counting devices by vendor and model and summing their ports:
+//
+//	func(elem interface{}) []*inventory.Facet {
+//	    d := elem.(*Device)
+//	    ports := float64(len(d.Ports))
+//	    return []*inventory.Facet{{Name: d.Vendor, Value: ports,
+//	        Facets: []*inventory.Facet{{Name: d.Model, Value: ports}}}}
+//	}
+type MetadataFunc func(elem interface{}) []*Facet
+
+// FacetCounts aggregates the facets of the elements in a bucket.
+type FacetCounts struct {
+	// Count is the number of elements in the bucket. At the top level of a
+	// metadata, it is the number of elements the function returned facets for.
+	Count int
+	// Sum is the total of the facet values
+	Sum float64
+	// Buckets holds the counts of the named buckets within this one
+	Buckets map[string]*FacetCounts
+}
+
+// Average returns the mean facet value of the bucket's elements, 0 if it has none.
+func (this *FacetCounts) Average() float64 {
+	if this.Count == 0 {
+		return 0
+	}
+	return this.Sum / float64(this.Count)
+}
+
+// Facets holds the counts of every typed metadata function by name.
+type Facets map[string]*FacetCounts
+
+// typedMetadata is a registered metadata function, typed or wrapping one
+// registered with AddMetadata.
+type typedMetadata struct {
+	name string
+	f    MetadataFunc
+}
+
+// bucket returns the counts of the named bucket, creating it if needed.
+func (this *FacetCounts) bucket(name string) *FacetCounts {
+	if this.Buckets == nil {
+		this.Buckets = make(map[string]*FacetCounts)
+	}
+	counts := this.Buckets[name]
+	if counts == nil {
+		counts = &FacetCounts{}
+		this.Buckets[name] = counts
+	}
+	return counts
+}
+
+// addElement adds the facets of an element to the top level counts of a metadata,
+// or removes them if sign is -1. Buckets left without elements are removed.
+func (this *FacetCounts) addElement(facets []*Facet, sign int) {
+	if len(facets) == 0 {
+		return
+	}
+	this.Count += sign
+	for _, facet := range facets {
+		this.Sum += float64(sign) * facet.Value
+	}
+	this.addFacets(facets, sign)
+}
+
+// addFacets adds the named facets of an element to their buckets within this one,
+// and their nested facets to the nested buckets, or removes them if sign is -1.
+// Facets with the same name count the element once, with their values summed and
+// their nested facets merged.
+func (this *FacetCounts) addFacets(facets []*Facet, sign int) {
+	var names []string
+	nested := make(map[string][]*Facet)
+	for _, facet := range facets {
+		if facet.Name == "" {
+			continue
+		}
+		bucket := this.bucket(facet.Name)
+		if _, ok := nested[facet.Name]; !ok {
+			names = append(names, facet.Name)
+			bucket.Count += sign
+		}
+		bucket.Sum += float64(sign) * facet.Value
+		nested[facet.Name] = append(nested[facet.Name], facet.Facets...)
+	}
+	for _, name := range names {
+		bucket := this.Buckets[name]
+		bucket.addFacets(nested[name], sign)
+		if bucket.Count <= 0 {
+			delete(this.Buckets, name)
+		}
+	}
+}
+
+// clone returns a deep copy of the counts.
+func (this *FacetCounts) clone() *FacetCounts {
+	copied := &FacetCounts{Count: this.Count, Sum: this.Sum}
+	for name, bucket := range this.Buckets {
+		if copied.Buckets == nil {
+			copied.Buckets = make(map[string]*FacetCounts, len(this.Buckets))
+		}
+		copied.Buckets[name] = bucket.clone()
+	}
+	return copied
+}
+
+// facetKeyEscaper escapes the separator of the FacetKey path segments.
+var facetKeyEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`)
+
+// FacetKey returns the key of a metadata count in the metadata returned by Get:
+// the path from the metadata name to the bucket, joined by dots. Backslashes and
+// dots within a name are escaped with a backslash, so bucket names holding them
+// cannot collide with nested buckets.
+//
+// Example:
+//
+//	count := metadata.KeyCount.Counts[inventory.FacetKey("vendor", "Cisco", "C9300")]
+func FacetKey(path ...string) string {
+	escaped := make([]string, len(path))
+	for i, name := range path {
+		escaped[i] = facetKeyEscaper.Replace(name)
+	}
+	return strings.Join(escaped, ".")
+}
+
+// metaData adds the counts to the query metadata, creating it if nil, keyed as
+// described by InventoryCenter.Facets. The metadata must not be shared.
+func (this Facets) metaData(stats *l8api.L8MetaData) *l8api.L8MetaData {
+	if stats == nil {
+		stats = &l8api.L8MetaData{}
+	}
+	if stats.KeyCount == nil {
+		stats.KeyCount = &l8api.L8Count{}
+	}
+	if stats.KeyCount.Counts == nil {
+		stats.KeyCount.Counts = make(map[string]int32)
+	}
+	var add func(key string, counts *FacetCounts)
+	add = func(key string, counts *FacetCounts) {
+		stats.KeyCount.Counts[key] = int32(min(counts.Count, math.MaxInt32))
+		for name, bucket := range counts.Buckets {
+			add(key+"."+FacetKey(name), bucket)
+		}
+	}
+	for name, counts := range this {
+		add(FacetKey(name), counts)
+	}
+	return stats
+}
+
+// Proto returns the wire form of the facets, with their exact sums and averages.
+// Metadata and buckets are ordered by name.
+func (this Facets) Proto() *l8inventory.L8InventoryFacets {
+	pb := &l8inventory.L8InventoryFacets{}
+	for _, name := range sortedNames(this) {
+		pb.Metadata = append(pb.Metadata, this[name].proto(name))
+	}
+	return pb
+}
+
+// proto returns the wire form of the counts of the named bucket.
+func (this *FacetCounts) proto(name string) *l8inventory.L8InventoryFacet {
+	pb := &l8inventory.L8InventoryFacet{Name: name, Count: int64(this.Count), Sum: this.Sum, Average: this.Average()}
+	for _, bucket := range sortedNames(this.Buckets) {
+		pb.Buckets = append(pb.Buckets, this.Buckets[bucket].proto(bucket))
+	}
+	return pb
+}
+
+// sortedNames returns the names of the counts in order.
+func sortedNames(counts map[string]*FacetCounts) []string {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FacetsOf returns the facets carried by their wire form.
+func FacetsOf(pb *l8inventory.L8InventoryFacets) Facets {
+	facets := make(Facets, len(pb.GetMetadata()))
+	for _, m := range pb.GetMetadata() {
+		facets[m.Name] = facetCountsOf(m)
+	}
+	return facets
+}
+
+// facetCountsOf returns the counts carried by a bucket's wire form.
+func facetCountsOf(pb *l8inventory.L8InventoryFacet) *FacetCounts {
+	counts := &FacetCounts{Count: int(pb.Count), Sum: pb.Sum}
+	for _, bucket := range pb.Buckets {
+		if counts.Buckets == nil {
+			counts.Buckets = make(map[string]*FacetCounts, len(pb.Buckets))
+		}
+		counts.Buckets[bucket.Name] = facetCountsOf(bucket)
+	}
+	return counts
+}
+
+// AddTypedMetadata registers a typed metadata function, which can put an element
+// in several buckets, nest buckets and sum numeric values. Its counts over the
+// whole inventory are maintained on every write and added to the metadata
+// returned by Get, as described by Facets, and are available with their exact
+// sums through Metadata and Facets.
+func (this *InventoryCenter) AddTypedMetadata(name string, f MetadataFunc) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.countMetadata(name, f)
+}
+
+// countMetadata starts maintaining the counts of a metadata function, seeding them
+// from the cached elements. Must be called with the write lock held.
+func (this *InventoryCenter) countMetadata(name string, f MetadataFunc) {
+	this.counted = append(this.counted, &typedMetadata{name: name, f: f})
+	counts := this.counts[name]
+	if counts == nil {
+		counts = &FacetCounts{}
+		this.counts[name] = counts
+	}
+	err := this.scanLocked(func(elem interface{}) {
+		counts.addElement(f(elem), 1)
+	})
+	if err != nil {
+		this.resources.Logger().Error("failed to count metadata ", name, " of ", this.serviceName, ": ", err.Error())
+	}
+}
+
+// countChange updates the element count and the metadata counts with a write that
+// replaced old by new. existed reports whether the element was cached before the
+// write, in which case old holds its previous state whenever metadata is counted;
+// new is nil if the element does not exist after. Must be called with the write
+// lock held.
+func (this *InventoryCenter) countChange(existed bool, old, new interface{}) {
+	if existed {
+		this.count--
+		for _, m := range this.counted {
+			this.counts[m.name].addElement(m.f(old), -1)
+		}
+	}
+	if new != nil {
+		this.count++
+		for _, m := range this.counted {
+			this.counts[m.name].addElement(m.f(new), 1)
+		}
+	}
+}
+
+// Metadata returns the counts of every metadata function registered with
+// AddMetadata or AddTypedMetadata over the whole inventory, without running a
+// query. The counts are maintained on every write, so reading them takes time
+// proportional to the number of buckets, not elements. A function registered with
+// AddMetadata puts an element in the bucket of the value it returns. Placeholders
+// are counted like other elements.
+//
+// Example:
+//
+//	byStatus := center.Metadata()["status"]
+//	fmt.Println(byStatus.Count, byStatus.Buckets["UP"].Count)
+func (this *InventoryCenter) Metadata() Facets {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	facets := make(Facets, len(this.counts))
+	for name, counts := range this.counts {
+		facets[name] = counts.clone()
+	}
+	return facets
+}
+
+// Count returns the number of cached elements, placeholders included, maintained
+// on every write.
+func (this *InventoryCenter) Count() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.count
+}
+
+// Facets returns the counts of every metadata function over the elements matching
+// the query, regardless of the query's page and limit. If query is nil, they are
+// the counts over the whole inventory maintained on every write, as returned by
+// Metadata; otherwise the matching elements are scanned under the write lock.
+//
+// The metadata returned by Get holds the maintained counts, not those of its
+// query, so a query does not scan the inventory for them. Each metadata and
+// bucket is a count keyed by FacetKey of its path from the metadata name, e.g.
+// "vendor.Cisco" or "vendor.Cisco.C9300", capped at 2^31-1. Sums and averages are
+// not in the Get metadata; a GET carrying an l8inventory.L8InventoryFacets returns
+// the facets of its query with them, in the form of Facets.Proto.
+//
+// Example:
+//
+//	facets, err := center.Facets(nil)
+//	cisco := facets["vendor"].Buckets["Cisco"]
+//	fmt.Println(cisco.Count, cisco.Sum, facets["vendor"].Sum)
+func (this *InventoryCenter) Facets(query ifs.IQuery) (Facets, error) {
+	if query == nil {
+		return this.Metadata(), nil
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.facets(query), nil
+}
+
+// facets evaluates the metadata functions over every element matching the query.
+// Must be called with the write lock held.
+func (this *InventoryCenter) facets(query ifs.IQuery) Facets {
+	facets := make(Facets, len(this.counted))
+	for _, m := range this.counted {
+		facets[m.name] = &FacetCounts{}
+	}
+	if len(this.counted) == 0 {
+		return facets
+	}
+	for start := 0; ; start += exportBlock {
+		elems, _ := this.elements.Fetch(start, exportBlock, query)
+		for _, elem := range elems {
+			for _, m := range this.counted {
+				facets[m.name].addElement(m.f(elem), 1)
+			}
+		}
+		if len(elems) < exportBlock {
+			return facets
+		}
+	}
+}
+
+// facets returns the metadata counts of the elements matching the query of a GET
+// carrying an l8inventory.L8InventoryFacets, or the counts maintained over the
+// whole inventory if it has none.
+func (this *InventoryService) facets(pb *l8inventory.L8InventoryFacets) ifs.IElements {
+	var query ifs.IQuery
+	if pb.Query != "" {
+		elems, err := object.NewQuery(pb.Query, this.nic.Resources())
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		if query, err = elems.Query(this.nic.Resources()); err != nil {
+			return object.NewError(err.Error())
+		}
+	}
+	facets, err := this.inventoryCenter.Facets(query)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	return object.New(nil, facets.Proto())
+}
@@ -0,0 +1,226 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultFileSinkMaxFiles is the number of rotated files kept when
+// FileSinkConfig.MaxFiles is not set.
+const DefaultFileSinkMaxFiles = 5
+
+// FileSinkConfig configures an append-only JSON Lines file sink.
+type FileSinkConfig struct {
+	// Path is the file changes are appended to
+	Path string
+	// MaxBytes rotates the file once it grows beyond this size. Zero disables rotation.
+	MaxBytes int64
+	// MaxFiles is the number of rotated files kept (Path.1 being the newest)
+	MaxFiles int
+}
+
+// ChangeRecord is the JSON representation of a forwarded element written by the
+// file sink, one per line.
+type ChangeRecord struct {
+	// Time is the time the batch was written, in milliseconds
+	Time int64 `json:"time"`
+	// Action is the forwarded action, e.g. "PATCH"
+	Action string `json:"action"`
+	// Element is the element in protojson format
+	Element json.RawMessage `json:"element"`
+}
+
+// fileSink appends forwarded elements to a JSON Lines file, rotating it by size.
+type fileSink struct {
+	cfg  *FileSinkConfig
+	file *os.File
+	size int64
+	mtx  *sync.Mutex
+}
+
+// NewFileSink creates a sink appending every forwarded element as a ChangeRecord
+// line to the configured file. The file is created if missing.
+//
+// Example:
+//
+//	sink, err := inventory.NewFileSink(&inventory.FileSinkConfig{Path: "/data/devices.jsonl", MaxBytes: 100 << 20})
+//	svc.AddSink(&inventory.SinkConfig{Name: "file", Sink: sink})
+func NewFileSink(cfg *FileSinkConfig) (Sink, error) {
+	if cfg == nil || cfg.Path == "" {
+		return nil, errors.New("file sink path is required")
+	}
+	copied := *cfg
+	if copied.MaxFiles <= 0 {
+		copied.MaxFiles = DefaultFileSinkMaxFiles
+	}
+	this := &fileSink{cfg: &copied, mtx: &sync.Mutex{}}
+	if err := this.open(); err != nil {
+		return nil, err
+	}
+	return this, nil
+}
+
+// open opens the sink file for appending and records its current size.
+func (this *fileSink) open() error {
+	file, err := os.OpenFile(this.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	this.file = file
+	this.size = info.Size()
+	return nil
+}
+
+// Send appends one line per element, rotating the file first if it is full.
+func (this *fileSink) Send(action ifs.Action, elements []interface{}) error {
+	buff, err := appendRecords(nil, time.Now().UnixMilli(), action, elements)
+	if err != nil {
+		return err
+	}
+	return this.write(buff)
+}
+
+// SendTransaction appends one line per operation of the transaction in a single
+// write, so the file never holds only part of it.
+func (this *fileSink) SendTransaction(tx *Transaction) error {
+	now := time.Now().UnixMilli()
+	var buff []byte
+	var err error
+	for _, op := range tx.Ops {
+		if buff, err = appendRecords(buff, now, op.Action, []interface{}{op.Element}); err != nil {
+			return err
+		}
+	}
+	return this.write(buff)
+}
+
+// appendRecords appends the ChangeRecord line of every element to buff.
+func appendRecords(buff []byte, now int64, action ifs.Action, elements []interface{}) ([]byte, error) {
+	records, err := encodeElements(elements)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		line, err := json.Marshal(&ChangeRecord{Time: now, Action: actionName(action), Element: record})
+		if err != nil {
+			return nil, err
+		}
+		buff = append(buff, line...)
+		buff = append(buff, '\n')
+	}
+	return buff, nil
+}
+
+// write appends the lines to the file, rotating it first if it is full.
+func (this *fileSink) write(buff []byte) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.file == nil {
+		return errors.New("file sink " + this.cfg.Path + " is closed")
+	}
+	if this.cfg.MaxBytes > 0 && this.size > 0 && this.size+int64(len(buff)) > this.cfg.MaxBytes {
+		if err := this.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := this.file.Write(buff)
+	this.size += int64(n)
+	return err
+}
+
+// rotate shifts Path.N to Path.N+1, dropping the oldest, moves the current file to
+// Path.1 and opens a new one. The file is reopened even if rotating failed, so the
+// next Send can try again. Must be called with the lock held.
+func (this *fileSink) rotate() error {
+	err := this.file.Close()
+	this.file = nil
+	if err == nil {
+		os.Remove(this.rotated(this.cfg.MaxFiles))
+		for i := this.cfg.MaxFiles - 1; i >= 1; i-- {
+			os.Rename(this.rotated(i), this.rotated(i+1))
+		}
+		err = os.Rename(this.cfg.Path, this.rotated(1))
+	}
+	if openErr := this.open(); openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	return err
+}
+
+// rotated returns the name of the i-th rotated file.
+func (this *fileSink) rotated(i int) string {
+	return this.cfg.Path + "." + strconv.Itoa(i)
+}
+
+// Close closes the sink file.
+func (this *fileSink) Close() error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.file == nil {
+		return nil
+	}
+	err := this.file.Close()
+	this.file = nil
+	return err
+}
+
+// encodeElements converts elements to protojson.
+func encodeElements(elements []interface{}) ([]json.RawMessage, error) {
+	result := make([]json.RawMessage, 0, len(elements))
+	for _, element := range elements {
+		pb, ok := element.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("element of type %T is not a proto message", element)
+		}
+		data, err := protojson.Marshal(pb)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// actionName returns the name of an action as used in sink payloads.
+func actionName(action ifs.Action) string {
+	switch action {
+	case ifs.POST:
+		return "POST"
+	case ifs.PUT:
+		return "PUT"
+	case ifs.PATCH:
+		return "PATCH"
+	case ifs.DELETE:
+		return "DELETE"
+	case ifs.GET:
+		return "GET"
+	}
+	return strconv.Itoa(int(action))
+}
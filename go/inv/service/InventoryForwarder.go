@@ -0,0 +1,478 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Default forwarding settings, used for any ForwardConfig field left at zero.
+const (
+	DefaultForwardBatchSize     = 5
+	DefaultForwardFlushInterval = 30 * time.Second
+	DefaultForwardMaxInFlight   = 1
+	DefaultForwardRetryBackoff  = time.Second
+)
+
+// forwardMinTick is the shortest time the flush timer sleeps, so a very small
+// FlushInterval does not turn it into a busy loop.
+const forwardMinTick = 10 * time.Millisecond
+
+// ForwardConfig controls how changes applied to an inventory are batched before
+// being forwarded to the linked downstream service. A *ForwardConfig can be passed
+// as an SLA argument following the links id, and replaced at runtime through
+// InventoryService.SetForwardConfig. Zero fields fall back to the defaults.
+//
+// Example:
+//
+//	sla.SetArgs(linksId, &inventory.ForwardConfig{BatchSize: 500, FlushInterval: time.Second})
+type ForwardConfig struct {
+	// BatchSize is the number of pending elements that triggers a flush
+	BatchSize int
+	// BatchBytes is the accumulated serialized size of pending elements that
+	// triggers a flush. Zero disables size-based flushing.
+	BatchBytes int
+	// FlushInterval is the longest time a pending element waits before it is flushed
+	FlushInterval time.Duration
+	// MaxInFlight is the maximum number of forward requests awaiting a response.
+	// Batches are started in order, but with more than one in flight they are
+	// sent concurrently and may complete, or reach the sink, out of order.
+	MaxInFlight int
+	// Retries is the number of times a batch whose send failed is sent again before
+	// it is reported to the sink as failed. Zero disables retries. Sends failing
+	// with a PermanentError are not retried.
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubled on every further retry
+	RetryBackoff time.Duration
+}
+
+// withDefaults returns a copy of the configuration with zero fields replaced by
+// the package defaults. A nil receiver yields the default configuration.
+func (this *ForwardConfig) withDefaults() *ForwardConfig {
+	cfg := &ForwardConfig{}
+	if this != nil {
+		*cfg = *this
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultForwardBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultForwardFlushInterval
+	}
+	if cfg.MaxInFlight <= 0 {
+		cfg.MaxInFlight = DefaultForwardMaxInFlight
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DefaultForwardRetryBackoff
+	}
+	return cfg
+}
+
+// forwardOp is a single pending element together with the action to forward it with.
+// An op whose pending operations cancelled each other out is marked as dropped and
+// skipped at flush time.
+type forwardOp struct {
+	action  ifs.Action
+	element interface{}
+	size    int
+	dropped bool
+}
+
+// forwardBatch is a run of elements flushed together under the same action, or
+// the operations of a transaction forwarded as one unit.
+type forwardBatch struct {
+	action   ifs.Action
+	elements []interface{}
+	// tx holds the operations of a transaction, nil for a run of elements
+	tx *Transaction
+	// sent is the number of runs of the transaction already delivered to a sink
+	// that does not implement TransactionSink, so a retry resumes after them
+	sent int
+}
+
+// size returns the number of elements of the batch.
+func (this *forwardBatch) size() int {
+	if this.tx != nil {
+		return len(this.tx.Ops)
+	}
+	return len(this.elements)
+}
+
+// runs splits the operations of a transaction into consecutive runs of the same
+// action, preserving their order.
+func (this *forwardBatch) runs() []*forwardBatch {
+	var runs []*forwardBatch
+	var run *forwardBatch
+	for _, op := range this.tx.Ops {
+		if run == nil || run.action != op.Action {
+			run = &forwardBatch{action: op.Action}
+			runs = append(runs, run)
+		}
+		run.elements = append(run.elements, op.Element)
+	}
+	return runs
+}
+
+// forwarder batches inventory changes and hands them to a sink, flushing when the
+// batch size, batch bytes or flush interval thresholds are reached. Pending
+// operations on the same primary key are coalesced into a single operation before
+// the flush. Flushed batches are dispatched in order, with at most MaxInFlight sends
+// outstanding; the sink receives them in order only with a MaxInFlight of 1. A batch whose send still fails after its retries is reported to the
+// sink if it implements FailureSink. The operations of a transaction are neither
+// coalesced nor split across batches.
+type forwarder struct {
+	sink      Sink
+	keyOf     func(interface{}) string
+	resources ifs.IResources
+	cfg       *ForwardConfig
+	pending   []*forwardOp
+	index     map[string]*forwardOp
+	count     int
+	bytes     int
+	oldest    time.Time
+	queue     []*forwardBatch
+	inFlight  int
+	running   bool
+	cond      *sync.Cond
+}
+
+// newForwarder creates a forwarder with the given configuration and starts its
+// flush timer and dispatch loop. keyOf returns the primary key of an element, used
+// to coalesce pending operations.
+func newForwarder(cfg *ForwardConfig, sink Sink, keyOf func(interface{}) string, resources ifs.IResources) *forwarder {
+	this := &forwarder{}
+	this.sink = sink
+	this.keyOf = keyOf
+	this.resources = resources
+	this.index = make(map[string]*forwardOp)
+	this.cfg = cfg.withDefaults()
+	this.cond = sync.NewCond(&sync.Mutex{})
+	this.running = true
+	go this.timer()
+	go this.dispatch()
+	return this
+}
+
+// setConfig replaces the forwarding configuration. Pending elements are flushed
+// if they already exceed the new thresholds, and a raised MaxInFlight takes effect
+// for the next dispatched batch.
+func (this *forwarder) setConfig(cfg *ForwardConfig) {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	this.cfg = cfg.withDefaults()
+	if this.full() {
+		this.flush()
+	}
+	this.cond.Broadcast()
+}
+
+// config returns a copy of the forwarding configuration in effect.
+func (this *forwarder) config() *ForwardConfig {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	cfg := *this.cfg
+	return &cfg
+}
+
+// add queues operations for forwarding. An operation whose primary key already has
+// a pending operation is coalesced into it. If tx is set, the operations belong to
+// a transaction: the pending operations are flushed first and the transaction is
+// queued as a single batch behind them.
+func (this *forwarder) add(ops []*forwardOp, tx bool) {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	if !this.running {
+		return
+	}
+	if tx {
+		this.flush()
+		batch := &forwardBatch{tx: NewTransaction()}
+		for _, op := range ops {
+			if op.element != nil {
+				batch.tx.add(op.action, op.element)
+			}
+		}
+		if len(batch.tx.Ops) > 0 {
+			this.queue = append(this.queue, batch)
+			this.cond.Broadcast()
+		}
+		return
+	}
+	for _, op := range ops {
+		if op.element == nil {
+			continue
+		}
+		key := this.keyOf(op.element)
+		if prev, ok := this.index[key]; ok && this.coalesce(key, prev, op.action, op.element) {
+			continue
+		}
+		op.size = sizeOf(op.element)
+		if this.count == 0 {
+			this.oldest = time.Now()
+		}
+		this.pending = append(this.pending, op)
+		this.index[key] = op
+		this.count++
+		this.bytes += op.size
+	}
+	if this.full() {
+		this.flush()
+	}
+}
+
+// coalesce folds a new operation on a key into the operation already pending for
+// it, so only the net effect is forwarded:
+//   - POST followed by DELETE cancels out
+//   - any other operation followed by DELETE becomes a DELETE
+//   - a PATCH is merged into the pending element, keeping the pending action
+//   - a PATCH after a DELETE is not folded, both are forwarded in order
+//   - a POST or PUT replaces the pending element, staying a POST after a POST and
+//     becoming a PUT otherwise
+//
+// Pending PATCH elements only carry their changed fields, so merging two of them
+// yields a PATCH of both changes and merging one into a POST or PUT yields the full
+// element, while replacing a DELETE by one would forward a partial element.
+// Returns false if the operation was not folded and must be queued on its own.
+// Must be called with the lock held.
+func (this *forwarder) coalesce(key string, prev *forwardOp, action ifs.Action, element interface{}) bool {
+	if action == ifs.PATCH && prev.action == ifs.DELETE {
+		return false
+	}
+	this.bytes -= prev.size
+	switch {
+	case action == ifs.DELETE && prev.action == ifs.POST:
+		prev.dropped = true
+		delete(this.index, key)
+		this.count--
+		return true
+	case action == ifs.DELETE:
+		prev.action = ifs.DELETE
+		prev.element = element
+	case action == ifs.PATCH:
+		prev.element = mergeElements(prev.element, element)
+	default:
+		if prev.action != ifs.POST {
+			prev.action = ifs.PUT
+		}
+		prev.element = element
+	}
+	prev.size = sizeOf(prev.element)
+	this.bytes += prev.size
+	return true
+}
+
+// mergeElements returns a copy of base with every top-level field set in delta
+// replaced by the delta's value. Unlike proto.Merge, repeated and map fields are
+// replaced rather than appended to, as a delta carries each changed field whole.
+func mergeElements(base, delta interface{}) interface{} {
+	pbase, ok := base.(proto.Message)
+	if !ok {
+		return delta
+	}
+	pdelta, ok := delta.(proto.Message)
+	if !ok {
+		return delta
+	}
+	result := proto.Clone(pbase).ProtoReflect()
+	proto.Clone(pdelta).ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		result.Set(fd, v)
+		return true
+	})
+	return result.Interface()
+}
+
+// sizeOf returns the serialized size of an element, or 0 if it is not a proto message.
+func sizeOf(element interface{}) int {
+	if pb, ok := element.(proto.Message); ok {
+		return proto.Size(pb)
+	}
+	return 0
+}
+
+// backlog returns the number of elements not yet handed to the sink.
+func (this *forwarder) backlog() int {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	count := this.count
+	for _, batch := range this.queue {
+		count += batch.size()
+	}
+	return count
+}
+
+// full reports whether the pending elements reached the batch size or bytes
+// threshold. Must be called with the lock held.
+func (this *forwarder) full() bool {
+	if this.count >= this.cfg.BatchSize {
+		return true
+	}
+	return this.cfg.BatchBytes > 0 && this.bytes >= this.cfg.BatchBytes
+}
+
+// flush moves the pending elements to the dispatch queue, splitting them into
+// consecutive runs of the same action so their order is preserved. Must be called
+// with the lock held.
+func (this *forwarder) flush() {
+	if len(this.pending) == 0 {
+		return
+	}
+	var batch *forwardBatch
+	for _, op := range this.pending {
+		if op.dropped {
+			continue
+		}
+		if batch == nil || batch.action != op.action {
+			batch = &forwardBatch{action: op.action}
+			this.queue = append(this.queue, batch)
+		}
+		batch.elements = append(batch.elements, op.element)
+	}
+	this.pending = nil
+	this.index = make(map[string]*forwardOp)
+	this.count = 0
+	this.bytes = 0
+	this.cond.Broadcast()
+}
+
+// timer flushes pending elements once the oldest of them waited for the flush
+// interval. It sleeps until the oldest element is due, waking at least every second
+// to pick up configuration changes and at most every forwardMinTick.
+func (this *forwarder) timer() {
+	for {
+		this.cond.L.Lock()
+		if !this.running {
+			this.cond.L.Unlock()
+			return
+		}
+		sleep := this.cfg.FlushInterval
+		if this.count > 0 {
+			if due := sleep - time.Since(this.oldest); due > 0 {
+				sleep = due
+			} else {
+				this.flush()
+			}
+		}
+		this.cond.L.Unlock()
+		sleep = min(max(sleep, forwardMinTick), time.Second)
+		time.Sleep(sleep)
+	}
+}
+
+// dispatch starts the sends of queued batches in order, waiting whenever
+// MaxInFlight sends are already outstanding. Sends run concurrently, so only a
+// MaxInFlight of 1 keeps them in order.
+func (this *forwarder) dispatch() {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	for {
+		if len(this.queue) > 0 && this.inFlight < this.cfg.MaxInFlight {
+			batch := this.queue[0]
+			this.queue = this.queue[1:]
+			this.inFlight++
+			go this.sendBatch(batch)
+			continue
+		}
+		if len(this.queue) == 0 && !this.running {
+			return
+		}
+		this.cond.Wait()
+	}
+}
+
+// sendBatch hands a single batch to the sink, retrying with a doubling backoff on
+// failure unless the error is a PermanentError, and releases its in-flight slot. The in-flight slot is held while
+// retrying, so with a MaxInFlight of 1 later batches are not sent ahead of it.
+func (this *forwarder) sendBatch(batch *forwardBatch) {
+	cfg := this.config()
+	backoff := cfg.RetryBackoff
+	err := this.send(batch)
+	var permanent *PermanentError
+	for retry := 1; err != nil && !errors.As(err, &permanent) && retry <= cfg.Retries; retry++ {
+		this.resources.Logger().Debug("Retrying forward of ", batch.size(), " elements (", retry,
+			"/", cfg.Retries, "): ", err.Error())
+		time.Sleep(backoff)
+		backoff *= 2
+		err = this.send(batch)
+	}
+	if err != nil {
+		this.resources.Logger().Error("Failed to forward ", batch.size(), " elements: ", err.Error())
+		if failures, ok := this.sink.(FailureSink); ok {
+			this.failed(failures, batch, err)
+		}
+	}
+	this.cond.L.Lock()
+	this.inFlight--
+	this.cond.Broadcast()
+	this.cond.L.Unlock()
+}
+
+// send makes a single attempt to deliver a batch. A transaction is handed whole to
+// a TransactionSink; other sinks receive its runs of the same action one after the
+// other, resuming after the runs delivered by a previous attempt.
+func (this *forwarder) send(batch *forwardBatch) error {
+	if batch.tx == nil {
+		return this.sink.Send(batch.action, batch.elements)
+	}
+	if txSink, ok := this.sink.(TransactionSink); ok {
+		return txSink.SendTransaction(batch.tx)
+	}
+	runs := batch.runs()
+	for ; batch.sent < len(runs); batch.sent++ {
+		if err := this.sink.Send(runs[batch.sent].action, runs[batch.sent].elements); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// failed reports the undelivered elements of a batch to the sink, a transaction
+// as its runs not yet delivered.
+func (this *forwarder) failed(failures FailureSink, batch *forwardBatch, err error) {
+	if batch.tx == nil {
+		failures.Failed(batch.action, batch.elements, err)
+		return
+	}
+	runs := batch.runs()
+	if _, ok := this.sink.(TransactionSink); ok {
+		batch.sent = 0
+	}
+	for _, run := range runs[batch.sent:] {
+		failures.Failed(run.action, run.elements, err)
+	}
+}
+
+// wait blocks until every flushed batch was sent.
+func (this *forwarder) wait() {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	for len(this.queue) > 0 || this.inFlight > 0 {
+		this.cond.Wait()
+	}
+}
+
+// shutdown flushes any pending elements and stops the forwarder once the
+// queued batches were dispatched.
+func (this *forwarder) shutdown() {
+	this.cond.L.Lock()
+	defer this.cond.L.Unlock()
+	this.flush()
+	this.running = false
+	this.cond.Broadcast()
+}
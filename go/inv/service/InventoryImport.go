@@ -0,0 +1,472 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ImportMode is the way imported elements are applied to an inventory.
+type ImportMode int
+
+const (
+	// ImportPost posts every element
+	ImportPost ImportMode = iota
+	// ImportPut puts every element, replacing existing elements with the same key
+	ImportPut
+	// ImportPatch patches every element into the existing element with the same key
+	ImportPatch
+	// ImportReplace puts every element and then deletes the elements whose key was
+	// not imported, so the inventory holds exactly the imported elements
+	ImportReplace
+)
+
+// Default import settings, used for any ImportConfig field left at zero.
+const (
+	DefaultImportBatchSize     = 500
+	DefaultImportProgressEvery = 1000
+	// maxImportLine bounds the length of a single JSON Lines line
+	maxImportLine = 16 << 20
+)
+
+// ImportConfig configures a bulk import.
+type ImportConfig struct {
+	// Format is the input format. CSV input starts with a header row of the
+	// column paths, as written by Export.
+	Format Format
+	// Mode is the way elements are applied
+	Mode ImportMode
+	// Validate, if set, is called for every decoded element after the built-in
+	// checks of its type and primary key. Elements it returns an error for are
+	// rejected.
+	Validate func(elem interface{}) error
+	// Progress, if set, is called with the summary so far every ProgressEvery
+	// rows and once the import ends
+	Progress func(summary *ImportSummary)
+	// ProgressEvery is the number of rows between Progress calls
+	ProgressEvery int
+	// BatchSize is the number of elements applied, forwarded and notified together
+	BatchSize int
+	// MaxRejects aborts the import once more rows were rejected. Zero for no limit.
+	MaxRejects int
+}
+
+// ImportReject describes a row that was not imported.
+type ImportReject struct {
+	// Row is the 1-based number of the row, not counting a CSV header
+	Row int
+	// Reason describes why the row was rejected
+	Reason string
+}
+
+// ImportSummary reports the outcome of a bulk import.
+type ImportSummary struct {
+	// Rows is the number of rows read
+	Rows int
+	// Applied is the number of elements that changed the inventory
+	Applied int
+	// Unchanged is the number of elements identical to the cached element
+	Unchanged int
+	// Deleted is the number of elements deleted by ImportReplace
+	Deleted int
+	// DeleteSkipped is set when ImportReplace skipped deleting the elements that
+	// were not imported, because rows were rejected and their keys are unknown
+	DeleteSkipped bool
+	// Rejected lists the rows that were not imported
+	Rejected []*ImportReject
+}
+
+// Import reads elements from r and applies them to the inventory. Rows that do not
+// decode, have the wrong type or an unset primary key field, fail the Validate
+// function, or whose write the cache fails are rejected and listed in the summary;
+// the import goes on with the next row unless MaxRejects is exceeded. An error is
+// returned if reading fails or the import is aborted, along with the summary of
+// the rows processed so far.
+//
+// Elements applied through InventoryCenter are neither forwarded nor notified;
+// use InventoryService.Import for that.
+//
+// Example:
+//
+//	summary, err := center.ImportFile("/data/cmdb.csv", &inventory.ImportConfig{Format: inventory.CSV,
+//	    Mode: inventory.ImportPut})
+func (this *InventoryCenter) Import(r io.Reader, cfg *ImportConfig) (*ImportSummary, error) {
+	return this.importFrom(r, cfg, nil)
+}
+
+// ImportFile imports the elements of a file. See Import.
+func (this *InventoryCenter) ImportFile(path string, cfg *ImportConfig) (*ImportSummary, error) {
+	return this.importFile(path, cfg, nil)
+}
+
+// Import reads elements from r and applies them to the inventory like
+// InventoryCenter.Import, forwarding and notifying every batch of changes.
+func (this *InventoryService) Import(r io.Reader, cfg *ImportConfig) (*ImportSummary, error) {
+	return this.inventoryCenter.importFrom(r, cfg, this.publish)
+}
+
+// ImportFile imports the elements of a file like InventoryCenter.ImportFile,
+// forwarding and notifying every batch of changes.
+func (this *InventoryService) ImportFile(path string, cfg *ImportConfig) (*ImportSummary, error) {
+	return this.inventoryCenter.importFile(path, cfg, this.publish)
+}
+
+// importFile opens a file and imports its elements.
+func (this *InventoryCenter) importFile(path string, cfg *ImportConfig, publish func([]*Change)) (*ImportSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return this.importFrom(file, cfg, publish)
+}
+
+// importFrom imports the elements read from r, handing every applied batch of
+// changes to publish if set.
+func (this *InventoryCenter) importFrom(r io.Reader, cfg *ImportConfig, publish func([]*Change)) (*ImportSummary, error) {
+	if cfg == nil {
+		cfg = &ImportConfig{}
+	}
+	copied := *cfg
+	if copied.BatchSize <= 0 {
+		copied.BatchSize = DefaultImportBatchSize
+	}
+	if copied.ProgressEvery <= 0 {
+		copied.ProgressEvery = DefaultImportProgressEvery
+	}
+	action := ifs.POST
+	switch copied.Mode {
+	case ImportPost:
+	case ImportPut, ImportReplace:
+		action = ifs.PUT
+	case ImportPatch:
+		action = ifs.PATCH
+	default:
+		return nil, fmt.Errorf("unsupported import mode %d", copied.Mode)
+	}
+	next, err := this.decoder(r, copied.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ImportSummary{}
+	imported := make(map[string]bool)
+	batch := make([]interface{}, 0, copied.BatchSize)
+	rows := make([]int, 0, copied.BatchSize)
+	apply := func() {
+		if len(batch) == 0 {
+			return
+		}
+		changes := make([]*Change, 0, len(batch))
+		for i, elem := range batch {
+			change := this.apply(action, elem, false)
+			switch {
+			case change.Err != nil:
+				summary.Rejected = append(summary.Rejected, &ImportReject{Row: rows[i], Reason: change.Err.Error()})
+			case change.NoOp():
+				summary.Unchanged++
+			default:
+				summary.Applied++
+			}
+			changes = append(changes, change)
+		}
+		batch = batch[:0]
+		rows = rows[:0]
+		if publish != nil {
+			publish(changes)
+		}
+	}
+	tooManyRejects := func() bool {
+		return copied.MaxRejects > 0 && len(summary.Rejected) > copied.MaxRejects
+	}
+	defer func() {
+		if copied.Progress != nil {
+			copied.Progress(summary)
+		}
+	}()
+
+	for {
+		elem, err := next()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *rowError
+		if err != nil && !errors.As(err, &rowErr) {
+			apply()
+			return summary, err
+		}
+		summary.Rows++
+		if err == nil {
+			err = this.validate(&TxOp{Action: action, Element: elem})
+		}
+		if err == nil && copied.Validate != nil {
+			err = copied.Validate(elem)
+		}
+		if err != nil {
+			summary.Rejected = append(summary.Rejected, &ImportReject{Row: summary.Rows, Reason: err.Error()})
+			if tooManyRejects() {
+				apply()
+				return summary, fmt.Errorf("import aborted after %d rejected rows", len(summary.Rejected))
+			}
+		} else {
+			if copied.Mode == ImportReplace {
+				imported[this.keyOf(elem)] = true
+			}
+			batch = append(batch, elem)
+			rows = append(rows, summary.Rows)
+			if len(batch) >= copied.BatchSize {
+				apply()
+				if tooManyRejects() {
+					return summary, fmt.Errorf("import aborted after %d rejected rows", len(summary.Rejected))
+				}
+			}
+		}
+		if copied.Progress != nil && summary.Rows%copied.ProgressEvery == 0 {
+			copied.Progress(summary)
+		}
+	}
+	apply()
+
+	if copied.Mode == ImportReplace {
+		if len(summary.Rejected) > 0 {
+			summary.DeleteSkipped = true
+			return summary, nil
+		}
+		return summary, this.deleteOthers(imported, summary, publish)
+	}
+	return summary, nil
+}
+
+// deleteOthers deletes the cached elements whose key is not in keys. Returns an
+// error if the cache failed any of the deletes.
+func (this *InventoryCenter) deleteOthers(keys map[string]bool, summary *ImportSummary, publish func([]*Change)) error {
+	query, err := this.exportQuery(nil)
+	if err != nil {
+		return err
+	}
+	var stale []interface{}
+	for start := 0; ; start += exportBlock {
+		elems, _ := this.elements.Fetch(start, exportBlock, query)
+		for _, elem := range elems {
+			if !keys[this.keyOf(elem)] {
+				stale = append(stale, this.keyElement(elem))
+			}
+		}
+		if len(elems) < exportBlock {
+			break
+		}
+	}
+	changes := make([]*Change, 0, len(stale))
+	failed := 0
+	var lastErr error
+	for _, elem := range stale {
+		change := this.apply(ifs.DELETE, elem, false)
+		if change.Err != nil {
+			failed++
+			lastErr = change.Err
+		} else if !change.NoOp() {
+			summary.Deleted++
+		}
+		changes = append(changes, change)
+	}
+	if publish != nil && len(changes) > 0 {
+		publish(changes)
+	}
+	if lastErr != nil {
+		return fmt.Errorf("failed to delete %d elements that were not imported: %s", failed, lastErr.Error())
+	}
+	return nil
+}
+
+// keyElement returns a new element holding only the primary key of elem.
+func (this *InventoryCenter) keyElement(elem interface{}) interface{} {
+	from := reflect.ValueOf(elem).Elem()
+	result := reflect.New(this.elementType)
+	for _, attr := range this.primaryKeyAttributes {
+		result.Elem().FieldByName(attr).Set(from.FieldByName(attr))
+	}
+	return result.Interface()
+}
+
+// rowError is the error of a single input row that does not decode. The rows
+// after it can still be read.
+type rowError struct {
+	err error
+}
+
+// Error returns the decoding error of the row.
+func (this *rowError) Error() string {
+	return this.err.Error()
+}
+
+// decoder returns a function reading the next element of the given format. The
+// function returns io.EOF at the end of the input, a *rowError for a row that
+// does not decode, and any other error when reading fails.
+func (this *InventoryCenter) decoder(r io.Reader, format Format) (func() (proto.Message, error), error) {
+	newElement := func() proto.Message {
+		return reflect.New(this.elementType).Interface().(proto.Message)
+	}
+	switch format {
+	case JSONLines:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+		return func() (proto.Message, error) {
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" {
+					continue
+				}
+				elem := newElement()
+				if err := protojson.Unmarshal([]byte(line), elem); err != nil {
+					return nil, &rowError{err: err}
+				}
+				return elem, nil
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}, nil
+	case ProtoDelimited:
+		reader := bufio.NewReader(r)
+		return func() (proto.Message, error) {
+			elem := newElement()
+			if err := protodelim.UnmarshalFrom(reader, elem); err != nil {
+				return nil, err
+			}
+			return elem, nil
+		}, nil
+	case CSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		header, err := reader.Read()
+		if err == io.EOF {
+			return func() (proto.Message, error) { return nil, io.EOF }, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		md := newElement().ProtoReflect().Descriptor()
+		paths := make([][]protoreflect.FieldDescriptor, len(header))
+		for i, column := range header {
+			if paths[i], err = fieldPath(md, strings.TrimSpace(column)); err != nil {
+				return nil, err
+			}
+		}
+		return func() (proto.Message, error) {
+			row, err := reader.Read()
+			if err != nil {
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					return nil, &rowError{err: err}
+				}
+				return nil, err
+			}
+			if len(row) != len(header) {
+				return nil, &rowError{err: fmt.Errorf("expected %d columns, got %d", len(header), len(row))}
+			}
+			elem := newElement()
+			for i, text := range row {
+				if text == "" {
+					continue
+				}
+				if err := setCSVValue(elem.ProtoReflect(), paths[i], text); err != nil {
+					return nil, &rowError{err: errors.New("column " + header[i] + ": " + err.Error())}
+				}
+			}
+			return elem, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported import format %d", format)
+}
+
+// setCSVValue parses the text of a CSV column into the field at the path,
+// creating the messages on the path as needed.
+func setCSVValue(m protoreflect.Message, path []protoreflect.FieldDescriptor, text string) error {
+	for _, fd := range path[:len(path)-1] {
+		m = m.Mutable(fd).Message()
+	}
+	fd := path[len(path)-1]
+	if fd.IsList() || fd.IsMap() || fd.Message() != nil {
+		holder := m.Type().New()
+		if err := protojson.Unmarshal([]byte(`{"`+fd.JSONName()+`":`+text+`}`), holder.Interface()); err != nil {
+			return err
+		}
+		m.Set(fd, holder.Get(fd))
+		return nil
+	}
+	value, err := scalarValue(fd, text)
+	if err != nil {
+		return err
+	}
+	m.Set(fd, value)
+	return nil
+}
+
+// scalarValue parses the text of a scalar field as written by Export.
+func scalarValue(fd protoreflect.FieldDescriptor, text string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(text)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := strconv.ParseInt(text, 10, 32)
+		return protoreflect.ValueOfInt32(int32(i)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := strconv.ParseInt(text, 10, 64)
+		return protoreflect.ValueOfInt64(i), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := strconv.ParseUint(text, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(u)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := strconv.ParseUint(text, 10, 64)
+		return protoreflect.ValueOfUint64(u), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(text, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(text, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(text), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(text)
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(text)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, errors.New("unknown enum value " + text)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+}
@@ -0,0 +1,359 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FuzzyConfig enables typo-tolerant lookup on string fields of the service item
+// when passed as an SLA argument.
+type FuzzyConfig struct {
+	// Fields are the dot separated paths, by proto or JSON field name, of the
+	// fields looked up by LookupFuzzy, e.g. "hostname". A field may be a string,
+	// a repeated string or a map with string values.
+	Fields []string
+}
+
+// FuzzyMatch is an element found by LookupFuzzy.
+type FuzzyMatch struct {
+	// Element is the cached element
+	Element interface{}
+	// Value is the field value that matched
+	Value string
+	// Distance is the edit distance between the looked up text and Value
+	Distance int
+}
+
+// indexElement updates the lookup indexes with the element now cached under the
+// key, nil if it was deleted. Must be called with the write lock held.
+func (this *InventoryCenter) indexElement(key string, elem interface{}) {
+	if this.search != nil {
+		this.search.update(key, elem)
+	}
+	if this.keys != nil {
+		this.keys.update(key, elem)
+	}
+	for _, index := range this.fuzzy {
+		index.update(key, elem)
+	}
+}
+
+// scanLocked calls f with every cached element. Must be called with the write
+// lock held.
+func (this *InventoryCenter) scanLocked(f func(elem interface{})) error {
+	query, err := this.exportQuery(nil)
+	if err != nil {
+		return err
+	}
+	for start := 0; ; start += exportBlock {
+		elems, _ := this.elements.Fetch(start, exportBlock, query)
+		for _, elem := range elems {
+			f(elem)
+		}
+		if len(elems) < exportBlock {
+			return nil
+		}
+	}
+}
+
+// keyIndex holds the cached elements by primary key, ordered case insensitively,
+// for prefix lookups. It is a radix tree, so keeping it up to date on a write
+// takes time proportional to the length of the key, not the number of keys. Each
+// element is stored under its lower case key followed by a zero byte and the key
+// itself, which keeps keys differing only in case apart, ordered by their exact
+// form.
+type keyIndex struct {
+	tree trie
+}
+
+// indexKey returns the key the element of a primary key is stored under.
+func indexKey(key string) string {
+	return strings.ToLower(key) + "\x00" + key
+}
+
+// update adds or refreshes the element of the key, or removes the key if the
+// element is nil.
+func (this *keyIndex) update(key string, elem interface{}) {
+	if elem == nil {
+		this.tree.delete(indexKey(key))
+		return
+	}
+	this.tree.put(indexKey(key), elem)
+}
+
+// LookupPrefix returns the elements whose primary key starts with the prefix,
+// ignoring case, ordered by key. Composite keys are matched as their values joined
+// by "::", e.g. "Home::nginx", with colons in the values escaped as "\:". At most
+// limit elements are returned, all of them if limit is 0. Placeholders are
+// included, as their keys are known. The key index is built on the first lookup
+// and then kept up to date on every write.
+//
+// Example:
+//
+//	devices := center.LookupPrefix("core-sw", 10)
+func (this *InventoryCenter) LookupPrefix(prefix string, limit int) []interface{} {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.keys == nil {
+		keys := &keyIndex{}
+		err := this.scanLocked(func(elem interface{}) {
+			keys.update(this.keyOf(elem), elem)
+		})
+		if err != nil {
+			this.resources.Logger().Error("failed to build the key index of ", this.serviceName, ": ", err.Error())
+			return nil
+		}
+		this.keys = keys
+	}
+	var result []interface{}
+	this.keys.tree.walkPrefix(strings.ToLower(prefix), func(key string, elem interface{}) bool {
+		if limit > 0 && len(result) == limit {
+			return false
+		}
+		result = append(result, elem)
+		return true
+	})
+	return result
+}
+
+// bkNode is a distinct value in a BK-tree, with the elements having it. Nodes
+// whose elements were all removed stay in the tree to keep it searchable.
+type bkNode struct {
+	// value is the lower case value the distances are computed on
+	value string
+	// matches holds the element and original value of every key with the value
+	matches  map[string]*FuzzyMatch
+	children map[int]*bkNode
+}
+
+// fuzzyIndex is a BK-tree of the values of a field, which finds the values
+// within an edit distance of a text without comparing it to every value.
+type fuzzyIndex struct {
+	path  []protoreflect.FieldDescriptor
+	root  *bkNode
+	nodes map[string]*bkNode
+	// values holds the values indexed for every key
+	values map[string][]string
+	// empty is the number of nodes without elements
+	empty int
+}
+
+// newFuzzyIndex creates an empty index of the field at the path.
+func newFuzzyIndex(path []protoreflect.FieldDescriptor) *fuzzyIndex {
+	return &fuzzyIndex{path: path, nodes: make(map[string]*bkNode), values: make(map[string][]string)}
+}
+
+// update replaces the values indexed for the key with those of the element, or
+// removes them if the element is nil.
+func (this *fuzzyIndex) update(key string, elem interface{}) {
+	for _, value := range this.values[key] {
+		node := this.nodes[strings.ToLower(value)]
+		delete(node.matches, key)
+		if len(node.matches) == 0 {
+			this.empty++
+		}
+	}
+	delete(this.values, key)
+	pb, ok := elem.(proto.Message)
+	if !ok || key == "" {
+		this.compact()
+		return
+	}
+	var values []string
+	seen := make(map[string]bool)
+	for _, value := range searchValues(pb.ProtoReflect(), this.path) {
+		if fold := strings.ToLower(value); !seen[fold] {
+			seen[fold] = true
+			values = append(values, value)
+			this.add(value, key, elem)
+		}
+	}
+	if len(values) > 0 {
+		this.values[key] = values
+	}
+	this.compact()
+}
+
+// add adds the element of the key under a value.
+func (this *fuzzyIndex) add(value, key string, elem interface{}) {
+	fold := strings.ToLower(value)
+	node := this.nodes[fold]
+	if node == nil {
+		node = &bkNode{value: fold, matches: make(map[string]*FuzzyMatch)}
+		this.nodes[fold] = node
+		this.insert(node)
+	} else if len(node.matches) == 0 {
+		this.empty--
+	}
+	node.matches[key] = &FuzzyMatch{Element: elem, Value: value}
+}
+
+// insert links a new node into the tree.
+func (this *fuzzyIndex) insert(node *bkNode) {
+	if this.root == nil {
+		this.root = node
+		return
+	}
+	parent := this.root
+	for {
+		d := editDistance(parent.value, node.value)
+		child := parent.children[d]
+		if child == nil {
+			if parent.children == nil {
+				parent.children = make(map[int]*bkNode)
+			}
+			parent.children[d] = node
+			return
+		}
+		parent = child
+	}
+}
+
+// compact rebuilds the tree once most of its nodes have no elements.
+func (this *fuzzyIndex) compact() {
+	if this.empty < 64 || this.empty*2 < len(this.nodes) {
+		return
+	}
+	nodes := this.nodes
+	this.root, this.nodes, this.empty = nil, make(map[string]*bkNode), 0
+	for fold, node := range nodes {
+		if len(node.matches) > 0 {
+			rebuilt := &bkNode{value: fold, matches: node.matches}
+			this.nodes[fold] = rebuilt
+			this.insert(rebuilt)
+		}
+	}
+}
+
+// find returns the matches of the values within maxDistance of the text.
+func (this *fuzzyIndex) find(text string, maxDistance int) []*FuzzyMatch {
+	if this.root == nil {
+		return nil
+	}
+	text = strings.ToLower(text)
+	var result []*FuzzyMatch
+	stack := []*bkNode{this.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		d := editDistance(text, node.value)
+		if d <= maxDistance {
+			for _, match := range node.matches {
+				result = append(result, &FuzzyMatch{Element: match.Element, Value: match.Value, Distance: d})
+			}
+		}
+		for cd, child := range node.children {
+			if cd >= d-maxDistance && cd <= d+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return result
+}
+
+// editDistance returns the Levenshtein distance between two strings, counting
+// runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// EnableFuzzy indexes the values of the given string fields for LookupFuzzy and
+// keeps the indexes up to date on every write. The fields are given as described
+// by FuzzyConfig.Fields.
+func (this *InventoryCenter) EnableFuzzy(fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("no fields to look up")
+	}
+	md := this.element.(proto.Message).ProtoReflect().Descriptor()
+	indexes := make(map[string]*fuzzyIndex, len(fields))
+	for _, field := range fields {
+		path, err := stringFieldPath(md, field)
+		if err != nil {
+			return err
+		}
+		indexes[field] = newFuzzyIndex(path)
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	err := this.scanLocked(func(elem interface{}) {
+		key := this.keyOf(elem)
+		for _, index := range indexes {
+			index.update(key, elem)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if this.fuzzy == nil {
+		this.fuzzy = make(map[string]*fuzzyIndex)
+	}
+	for field, index := range indexes {
+		this.fuzzy[field] = index
+	}
+	return nil
+}
+
+// LookupFuzzy returns the elements whose field has a value within maxDistance
+// edits (insertions, deletions or substitutions) of the text, ignoring case. The
+// matches are ordered by distance and value, and at most limit are returned, all
+// of them if limit is 0. Placeholders only match on their key fields. Returns an
+// error if the field was not enabled with EnableFuzzy or a FuzzyConfig.
+//
+// Example:
+//
+//	matches, err := center.LookupFuzzy("hostname", "cor-sw1", 2, 5)
+func (this *InventoryCenter) LookupFuzzy(field, text string, maxDistance, limit int) ([]*FuzzyMatch, error) {
+	this.mtx.Lock()
+	index := this.fuzzy[field]
+	if index == nil {
+		this.mtx.Unlock()
+		return nil, errors.New("fuzzy lookup is not enabled for field " + field)
+	}
+	matches := index.find(text, maxDistance)
+	this.mtx.Unlock()
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		return a.Value < b.Value
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
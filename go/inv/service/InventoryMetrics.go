@@ -0,0 +1,256 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// MetricsContentType is the content type of the Prometheus text exposition format
+// written by WriteMetrics and MetricsHandler.
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// centerStats counts the writes and queries served by an inventory replica. The
+// counters live in memory and start from zero when the process starts.
+type centerStats struct {
+	posts   atomic.Uint64
+	puts    atomic.Uint64
+	patches atomic.Uint64
+	deletes atomic.Uint64
+	queries atomic.Uint64
+}
+
+// mutated counts a write of the given action.
+func (this *centerStats) mutated(action ifs.Action) {
+	switch action {
+	case ifs.POST:
+		this.posts.Add(1)
+	case ifs.PUT:
+		this.puts.Add(1)
+	case ifs.PATCH:
+		this.patches.Add(1)
+	case ifs.DELETE:
+		this.deletes.Add(1)
+	}
+}
+
+// metricFamily is a metric name with its help text, type and samples.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+// metricSet collects the samples of several inventories by metric family, so
+// every family is written once with its HELP and TYPE lines.
+type metricSet struct {
+	families []*metricFamily
+	byName   map[string]*metricFamily
+}
+
+// newMetricSet creates a metric set with the inventory metric families, in the
+// order they are written.
+func newMetricSet() *metricSet {
+	this := &metricSet{byName: make(map[string]*metricFamily)}
+	this.family("l8inventory_elements", "gauge", "Number of elements in the inventory, placeholders excluded.")
+	this.family("l8inventory_placeholders", "gauge", "Number of placeholder elements no real data was written for yet.")
+	this.family("l8inventory_mutations_total", "counter", "Number of writes applied to the inventory by action.")
+	this.family("l8inventory_queries_total", "counter", "Number of queries served by the inventory.")
+	this.family("l8inventory_last_applied_timestamp_seconds", "gauge", "Time the inventory last applied a write.")
+	this.family("l8inventory_forward_backlog", "gauge", "Number of changes not yet forwarded to a sink.")
+	this.family("l8inventory_metadata_elements", "gauge", "Number of elements by metadata function and bucket.")
+	this.family("l8inventory_metadata_sum", "gauge", "Sum of the values of the elements by metadata function and bucket.")
+	return this
+}
+
+// family registers a metric family.
+func (this *metricSet) family(name, kind, help string) {
+	f := &metricFamily{name: name, help: help, kind: kind}
+	this.families = append(this.families, f)
+	this.byName[name] = f
+}
+
+// add adds a sample of a registered family. Labels are given as name, value pairs.
+func (this *metricSet) add(name string, value float64, labels ...string) {
+	var sample strings.Builder
+	sample.WriteString(name)
+	if len(labels) > 0 {
+		sample.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sample.WriteByte(',')
+			}
+			sample.WriteString(labels[i])
+			sample.WriteString(`="`)
+			sample.WriteString(labelEscaper.Replace(labels[i+1]))
+			sample.WriteByte('"')
+		}
+		sample.WriteByte('}')
+	}
+	sample.WriteByte(' ')
+	sample.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	f := this.byName[name]
+	f.samples = append(f.samples, sample.String())
+}
+
+// labelEscaper escapes label values as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// write writes the families that have samples in the text format.
+func (this *metricSet) write(w io.Writer) error {
+	buff := bufio.NewWriter(w)
+	for _, f := range this.families {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(buff, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, sample := range f.samples {
+			buff.WriteString(sample)
+			buff.WriteByte('\n')
+		}
+	}
+	return buff.Flush()
+}
+
+// collect adds the metrics of the inventory to the set. The element and metadata
+// counts are read from the counts maintained on every write.
+func (this *InventoryService) collect(set *metricSet) {
+	center := this.inventoryCenter
+	if center == nil {
+		return
+	}
+	labels := []string{"service", this.sla.ServiceName(), "area", strconv.Itoa(int(this.sla.ServiceArea()))}
+	with := func(extra ...string) []string {
+		return append(append([]string{}, labels...), extra...)
+	}
+
+	center.mtx.Lock()
+	elements, placeholders := center.count, center.placeholders
+	center.mtx.Unlock()
+	set.add("l8inventory_elements", float64(elements-placeholders), labels...)
+	set.add("l8inventory_placeholders", float64(placeholders), labels...)
+	stats := center.stats
+	set.add("l8inventory_mutations_total", float64(stats.posts.Load()), with("action", "post")...)
+	set.add("l8inventory_mutations_total", float64(stats.puts.Load()), with("action", "put")...)
+	set.add("l8inventory_mutations_total", float64(stats.patches.Load()), with("action", "patch")...)
+	set.add("l8inventory_mutations_total", float64(stats.deletes.Load()), with("action", "delete")...)
+	set.add("l8inventory_queries_total", float64(stats.queries.Load()), labels...)
+	if last := center.LastApplied(); last > 0 {
+		set.add("l8inventory_last_applied_timestamp_seconds", float64(last)/1000, labels...)
+	}
+
+	this.sinksMtx.RLock()
+	for _, route := range this.sinks {
+		set.add("l8inventory_forward_backlog", float64(route.fwd.backlog()), with("sink", route.cfg.Name)...)
+	}
+	this.sinksMtx.RUnlock()
+
+	metadata := center.Metadata()
+	for _, name := range sortedKeys(metadata) {
+		if sum := metadata[name].Sum; sum != 0 {
+			set.add("l8inventory_metadata_sum", sum, with("metadata", name)...)
+		}
+		addBuckets(set, metadata[name], with("metadata", name), 1)
+	}
+}
+
+// addBuckets adds the element count and sum of every bucket nested in the counts,
+// at any depth. A bucket is labelled with its name under "value" at the top level
+// and under "value_<depth>" below it, e.g. value="Cisco",value_2="C9300", so
+// bucket names never clash whatever characters they hold.
+func addBuckets(set *metricSet, counts *FacetCounts, labels []string, depth int) {
+	label := "value"
+	if depth > 1 {
+		label = "value_" + strconv.Itoa(depth)
+	}
+	for _, value := range sortedKeys(counts.Buckets) {
+		bucket := counts.Buckets[value]
+		bucketLabels := append(append([]string{}, labels...), label, value)
+		set.add("l8inventory_metadata_elements", float64(bucket.Count), bucketLabels...)
+		if bucket.Sum != 0 {
+			set.add("l8inventory_metadata_sum", bucket.Sum, bucketLabels...)
+		}
+		addBuckets(set, bucket, bucketLabels, depth+1)
+	}
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// WriteMetrics writes the metrics of the inventory in the Prometheus text format:
+// element and placeholder counts, write and query counters, the forward backlog of
+// every sink and the number of elements and sum of the values per bucket, nested
+// buckets included, of every metadata function registered with AddMetadata or
+// AddTypedMetadata. Every sample is labelled with the service name and area.
+//
+// The metrics are those of this node. The write and query counters count since
+// the process started and restart from zero with it, which Prometheus handles as
+// a counter reset.
+func (this *InventoryService) WriteMetrics(w io.Writer) error {
+	set := newMetricSet()
+	this.collect(set)
+	return set.write(w)
+}
+
+// metrics returns the metrics of the inventory in response to a GET carrying an
+// l8inventory.L8InventoryMetrics, so they are reachable through the web layer.
+func (this *InventoryService) metrics() ifs.IElements {
+	var text bytes.Buffer
+	if err := this.WriteMetrics(&text); err != nil {
+		return object.NewError(err.Error())
+	}
+	return object.New(nil, &l8inventory.L8InventoryMetrics{Text: text.String()})
+}
+
+// MetricsHandler returns an HTTP handler serving the metrics of every inventory
+// activated in this process in the Prometheus text format, for scraping by an
+// existing monitoring system. The metrics of a single inventory are also served
+// through its web service, as the text of an l8inventory.L8InventoryMetrics
+// returned by a GET carrying one.
+//
+// Example:
+//
+//	http.Handle("/metrics", inventory.MetricsHandler())
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set := newMetricSet()
+		for _, key := range activated.keys() {
+			if service := activated.get(key); service != nil {
+				service.collect(set)
+			}
+		}
+		w.Header().Set("Content-Type", MetricsContentType)
+		set.write(w)
+	})
+}
@@ -0,0 +1,141 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/saichler/l8types/go/types/l8notify"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NotifyConfig controls the content of the change notifications an inventory
+// sends. It can be passed as an SLA argument or set at runtime through
+// InventoryService.SetNotifyConfig.
+//
+// Every notification carries the full composite key of the element in ModelKey
+// (the primary key values joined by "::", colons in them escaped as "\:") and a
+// per-service Sequence, so clients can update rows in place and detect missed
+// notifications.
+type NotifyConfig struct {
+	// Fields adds one L8Notification per changed field of a Put or Patch, with
+	// PropertyId set to the field path and OldValue/NewValue to the JSON values.
+	// A value is empty if the field was not set.
+	Fields bool
+	// Element adds an L8Notification with an empty PropertyId holding the full
+	// element in protojson format: the new state, or the last state for a Delete.
+	Element bool
+	// Debounce collapses the changes to the same key within the window into one
+	// notification of their net effect. Zero notifies every change right away.
+	Debounce time.Duration
+	// MaxPerSecond caps the notifications sent per second. The first notification
+	// over the cap is replaced by a single bulk event with ModelKey BulkModelKey
+	// telling clients to re-query; the rest of that second is dropped. Zero
+	// disables the cap.
+	MaxPerSecond int
+}
+
+// DefaultNotifyConfig is used when no NotifyConfig is set: field diffs are sent,
+// the full element is not.
+var DefaultNotifyConfig = NotifyConfig{Fields: true}
+
+// SetNotifyConfig replaces the notification settings at runtime. Notifications
+// already waiting for a debounce window are sent when it ends.
+func (this *InventoryService) SetNotifyConfig(cfg *NotifyConfig) {
+	if cfg == nil {
+		cfg = &DefaultNotifyConfig
+	}
+	copied := *cfg
+	this.notifyCfg.Store(&copied)
+}
+
+// NotifyConfig returns a copy of the notification settings in effect.
+func (this *InventoryService) NotifyConfig() *NotifyConfig {
+	copied := *this.notifyCfg.Load()
+	return &copied
+}
+
+// notification builds the notification of a change. Returns nil for changes that
+// are not notified.
+func (this *InventoryService) notification(change *Change) *l8notify.L8NotificationSet {
+	if change.Element == nil || change.NoOp() {
+		return nil
+	}
+	nType, ok := notificationType(change.Action)
+	if !ok {
+		return nil
+	}
+	n := &l8notify.L8NotificationSet{
+		ServiceName: this.sla.ServiceName(),
+		ServiceArea: int32(this.sla.ServiceArea()),
+		ModelType:   reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name(),
+		ModelKey:    change.Key,
+		Type:        nType,
+		Sequence:    atomic.AddUint32(&this.sequence, 1),
+		Time:        time.Now().UnixMilli(),
+	}
+	cfg := this.notifyCfg.Load()
+	if cfg.Fields {
+		for _, field := range change.Fields {
+			n.NotificationList = append(n.NotificationList, &l8notify.L8Notification{
+				PropertyId: field.Path,
+				OldValue:   field.json(field.Old),
+				NewValue:   field.json(field.New),
+			})
+		}
+	}
+	if cfg.Element {
+		elem := change.New
+		if elem == nil {
+			elem = change.Old
+		}
+		if pb, ok := elem.(proto.Message); ok {
+			if data, err := protojson.Marshal(pb); err == nil {
+				n.NotificationList = append(n.NotificationList, &l8notify.L8Notification{NewValue: data})
+			}
+		}
+	}
+	return n
+}
+
+// json returns a value of the changed field in JSON format, or nil if the value
+// is not set.
+func (this *FieldChange) json(value protoreflect.Value) []byte {
+	if !value.IsValid() || this.field == nil || this.parent == nil {
+		return nil
+	}
+	return fieldJSON(this.parent, this.field, value)
+}
+
+// fieldJSON returns a field value in JSON format. The value is encoded with
+// protojson as part of a message of the parent type, so enums, int64s and nested
+// messages follow the protojson mapping. Returns nil if it cannot be encoded.
+func fieldJSON(parent protoreflect.MessageType, fd protoreflect.FieldDescriptor, value protoreflect.Value) []byte {
+	holder := parent.New()
+	holder.Set(fd, value)
+	data, err := protojson.Marshal(holder.Interface())
+	if err != nil {
+		return nil
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields[fd.JSONName()]
+}
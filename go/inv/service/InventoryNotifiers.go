@@ -0,0 +1,329 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// Notifier is a target for the change notifications of an inventory. Notify is
+// called synchronously for every change, so implementations must not block.
+type Notifier interface {
+	// Notify delivers the notification of a change applied with the given action
+	Notify(action ifs.Action, n *l8notify.L8NotificationSet) error
+	// Close releases the notifier's resources once no more notifications will be sent
+	Close() error
+}
+
+// BatchNotifier is implemented by notifiers that can deliver the notifications of
+// a transaction as one batch. Notifiers that do not implement it receive them as
+// consecutive Notify calls.
+type BatchNotifier interface {
+	// NotifyBatch delivers the notifications of a transaction, in order. The action
+	// is the action of every notification, or PUT if they differ.
+	NotifyBatch(action ifs.Action, sets []*l8notify.L8NotificationSet) error
+}
+
+// NotifierConfig describes a target an inventory sends its change notifications
+// to, together with the actions it accepts. NotifierConfigs can be passed as SLA
+// arguments or added at runtime through InventoryService.AddNotifier.
+//
+// An inventory activated without any NotifierConfig notifies the WebSocket service
+// WsServiceName/WsServiceArea. Once NotifierConfigs are given, only those targets
+// are notified; add WsNotifier() explicitly to keep the default alongside them.
+//
+// Example, a second UI gateway and an in-process event processor for deletions:
+//
+//	events := make(chan *l8notify.L8NotificationSet, 1024)
+//	sla.SetArgs(linksId, inventory.WsNotifier(),
+//	    &inventory.NotifierConfig{Name: "ui-eu", ServiceName: "websock", ServiceArea: 1},
+//	    &inventory.NotifierConfig{Name: "events", Notifier: inventory.NewChannelNotifier(events),
+//	        Actions: []ifs.Action{ifs.DELETE}})
+type NotifierConfig struct {
+	// Name identifies the notifier within the inventory and must be unique
+	Name string
+	// Notifier is the target. If nil, notifications are multicast to ServiceName/ServiceArea.
+	Notifier Notifier
+	// ServiceName is the Layer 8 service to multicast to when Notifier is nil
+	ServiceName string
+	// ServiceArea is the area of ServiceName
+	ServiceArea byte
+	// Actions lists the actions notified to the target, empty for all actions
+	Actions []ifs.Action
+}
+
+// WsNotifier returns the configuration of the default notifier, multicasting to
+// the WebSocket service WsServiceName/WsServiceArea.
+func WsNotifier() *NotifierConfig {
+	return &NotifierConfig{Name: WsServiceName, ServiceName: WsServiceName, ServiceArea: WsServiceArea}
+}
+
+// serviceNotifier multicasts notifications to a Layer 8 service.
+type serviceNotifier struct {
+	nic         ifs.IVNic
+	serviceName string
+	serviceArea byte
+}
+
+// NewServiceNotifier creates a notifier multicasting every notification to all
+// instances of the given Layer 8 service.
+func NewServiceNotifier(nic ifs.IVNic, serviceName string, serviceArea byte) Notifier {
+	return &serviceNotifier{nic: nic, serviceName: serviceName, serviceArea: serviceArea}
+}
+
+// Notify multicasts the notification to the service.
+func (this *serviceNotifier) Notify(action ifs.Action, n *l8notify.L8NotificationSet) error {
+	return this.nic.Multicast(this.serviceName, this.serviceArea, action, n)
+}
+
+// NotifyBatch multicasts the notifications to the service in a single message.
+func (this *serviceNotifier) NotifyBatch(action ifs.Action, sets []*l8notify.L8NotificationSet) error {
+	elements := make([]interface{}, len(sets))
+	for i, n := range sets {
+		elements[i] = n
+	}
+	return this.nic.Multicast(this.serviceName, this.serviceArea, action, elements)
+}
+
+// Close is a no-op for service notifiers.
+func (this *serviceNotifier) Close() error {
+	return nil
+}
+
+// channelNotifier hands notifications to an in-process channel.
+type channelNotifier struct {
+	ch chan<- *l8notify.L8NotificationSet
+}
+
+// NewChannelNotifier creates a notifier sending every notification to the channel.
+// Sends never block: a notification arriving while the channel is full is dropped
+// and reported as an error, so the channel should be buffered for the expected
+// bursts.
+func NewChannelNotifier(ch chan<- *l8notify.L8NotificationSet) Notifier {
+	return &channelNotifier{ch: ch}
+}
+
+// Notify sends the notification to the channel unless it is full.
+func (this *channelNotifier) Notify(action ifs.Action, n *l8notify.L8NotificationSet) error {
+	select {
+	case this.ch <- n:
+		return nil
+	default:
+		return errors.New("notification channel is full, dropped notification of " + n.ModelKey)
+	}
+}
+
+// Close is a no-op for channel notifiers; the channel is owned by its creator.
+func (this *channelNotifier) Close() error {
+	return nil
+}
+
+// notifierRoute is an active notifier with its action filter.
+type notifierRoute struct {
+	cfg     *NotifierConfig
+	actions map[ifs.Action]bool
+}
+
+// accepts reports whether the route notifies changes of the given action.
+func (this *notifierRoute) accepts(action ifs.Action) bool {
+	return len(this.actions) == 0 || this.actions[action]
+}
+
+// AddNotifier starts sending the inventory's change notifications to a new target.
+// Returns an error if the name is empty or taken, or no target is set.
+func (this *InventoryService) AddNotifier(cfg *NotifierConfig) error {
+	if cfg == nil || cfg.Name == "" {
+		return errors.New("notifier name is required")
+	}
+	notifier := cfg.Notifier
+	if notifier == nil {
+		if cfg.ServiceName == "" {
+			return errors.New("notifier " + cfg.Name + " has no notifier or service name")
+		}
+		notifier = NewServiceNotifier(this.nic, cfg.ServiceName, cfg.ServiceArea)
+	}
+	copied := *cfg
+	copied.Notifier = notifier
+	route := &notifierRoute{cfg: &copied, actions: make(map[ifs.Action]bool)}
+	for _, action := range cfg.Actions {
+		route.actions[action] = true
+	}
+	this.notifiersMtx.Lock()
+	defer this.notifiersMtx.Unlock()
+	for _, existing := range this.notifiers {
+		if existing.cfg.Name == cfg.Name {
+			return errors.New("notifier " + cfg.Name + " already exists")
+		}
+	}
+	this.notifiers = append(this.notifiers, route)
+	return nil
+}
+
+// RemoveNotifier stops sending notifications to the named target and closes it.
+// Returns false if no such notifier exists.
+func (this *InventoryService) RemoveNotifier(name string) bool {
+	this.notifiersMtx.Lock()
+	defer this.notifiersMtx.Unlock()
+	for i, route := range this.notifiers {
+		if route.cfg.Name == name {
+			this.notifiers = append(this.notifiers[:i], this.notifiers[i+1:]...)
+			route.close(this.nic.Resources())
+			return true
+		}
+	}
+	return false
+}
+
+// Notifiers returns the names of the active notifiers in the order they were added.
+func (this *InventoryService) Notifiers() []string {
+	this.notifiersMtx.RLock()
+	defer this.notifiersMtx.RUnlock()
+	names := make([]string, len(this.notifiers))
+	for i, route := range this.notifiers {
+		names[i] = route.cfg.Name
+	}
+	return names
+}
+
+// notify sends the notifications of the changes, debounced and rate limited
+// according to the NotifyConfig.
+func (this *InventoryService) notify(changes []*Change) {
+	this.limiter.add(changes)
+}
+
+// notifyBatch sends the notifications of the changes of a transaction as one batch
+// per notifier. The notifications waiting for a debounce window are sent first so
+// the order is kept; a transaction is neither debounced nor counted against the
+// rate cap.
+func (this *InventoryService) notifyBatch(changes []*Change) {
+	this.limiter.flush()
+	this.notifiersMtx.RLock()
+	defer this.notifiersMtx.RUnlock()
+	if len(this.notifiers) == 0 {
+		return
+	}
+	sets := make([]*l8notify.L8NotificationSet, len(changes))
+	for i, change := range changes {
+		sets[i] = this.notification(change)
+	}
+	for _, route := range this.notifiers {
+		batch, ok := route.cfg.Notifier.(BatchNotifier)
+		if !ok {
+			for i, change := range changes {
+				if sets[i] != nil && route.accepts(change.Action) {
+					route.notify(change.Action, sets[i], this.nic.Resources())
+				}
+			}
+			continue
+		}
+		var accepted []*l8notify.L8NotificationSet
+		var action ifs.Action
+		for i, change := range changes {
+			if sets[i] == nil || !route.accepts(change.Action) {
+				continue
+			}
+			if len(accepted) == 0 {
+				action = change.Action
+			} else if action != change.Action {
+				action = ifs.PUT
+			}
+			accepted = append(accepted, sets[i])
+		}
+		if len(accepted) == 0 {
+			continue
+		}
+		if err := batch.NotifyBatch(action, accepted); err != nil {
+			this.nic.Resources().Logger().Error("Notifier ", route.cfg.Name, ": ", err.Error())
+		}
+	}
+}
+
+// sendNotifications sends the notification of every change to the notifiers
+// accepting its action. The notification of a change is built once and shared by
+// the targets.
+func (this *InventoryService) sendNotifications(changes []*Change) {
+	this.notifiersMtx.RLock()
+	defer this.notifiersMtx.RUnlock()
+	if len(this.notifiers) == 0 {
+		return
+	}
+	for _, change := range changes {
+		var n *l8notify.L8NotificationSet
+		for _, route := range this.notifiers {
+			if !route.accepts(change.Action) {
+				continue
+			}
+			if n == nil {
+				if n = this.notification(change); n == nil {
+					break
+				}
+			}
+			route.notify(change.Action, n, this.nic.Resources())
+		}
+	}
+}
+
+// notify sends a notification to the route's notifier, logging a failure.
+func (this *notifierRoute) notify(action ifs.Action, n *l8notify.L8NotificationSet, resources ifs.IResources) {
+	if err := this.cfg.Notifier.Notify(action, n); err != nil {
+		resources.Logger().Error("Notifier ", this.cfg.Name, ": ", err.Error())
+	}
+}
+
+// sendBulk sends a notification with ModelKey BulkModelKey to every notifier,
+// regardless of its action filter, telling it that changes were dropped.
+func (this *InventoryService) sendBulk() {
+	n := &l8notify.L8NotificationSet{
+		ServiceName: this.sla.ServiceName(),
+		ServiceArea: int32(this.sla.ServiceArea()),
+		ModelType:   reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name(),
+		ModelKey:    BulkModelKey,
+		Type:        l8notify.L8NotificationType_Put,
+		Sequence:    atomic.AddUint32(&this.sequence, 1),
+		Time:        time.Now().UnixMilli(),
+	}
+	this.notifiersMtx.RLock()
+	defer this.notifiersMtx.RUnlock()
+	for _, route := range this.notifiers {
+		if err := route.cfg.Notifier.Notify(ifs.PUT, n); err != nil {
+			this.nic.Resources().Logger().Error("Notifier ", route.cfg.Name, ": ", err.Error())
+		}
+	}
+}
+
+// closeNotifiers sends the notifications waiting for a debounce window and closes
+// every active notifier.
+func (this *InventoryService) closeNotifiers() {
+	this.limiter.flush()
+	this.notifiersMtx.Lock()
+	routes := this.notifiers
+	this.notifiers = nil
+	this.notifiersMtx.Unlock()
+	for _, route := range routes {
+		route.close(this.nic.Resources())
+	}
+}
+
+// close closes the route's notifier.
+func (this *notifierRoute) close(resources ifs.IResources) {
+	if err := this.cfg.Notifier.Close(); err != nil {
+		resources.Logger().Error("Failed to close notifier ", this.cfg.Name, ": ", err.Error())
+	}
+}
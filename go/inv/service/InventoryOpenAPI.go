@@ -0,0 +1,315 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OpenAPIPath is the well-known web path OpenAPIHandler serves the OpenAPI
+// documents from. The document of an inventory is at OpenAPIPath followed by
+// "<area>/<serviceName>", and OpenAPIPath itself lists the available documents.
+const OpenAPIPath = "/.well-known/openapi/"
+
+// inventories tracks the activated inventory services, so their OpenAPI documents
+// can be served without access to the services' resources.
+type inventories struct {
+	mtx      *sync.RWMutex
+	services map[string]*InventoryService
+}
+
+// activated holds every inventory service activated in this process.
+var activated = &inventories{mtx: &sync.RWMutex{}, services: make(map[string]*InventoryService)}
+
+// add registers an activated service.
+func (this *inventories) add(service *InventoryService) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.services[openAPIKey(service.sla.ServiceName(), service.sla.ServiceArea())] = service
+}
+
+// remove unregisters a deactivated service.
+func (this *inventories) remove(service *InventoryService) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	key := openAPIKey(service.sla.ServiceName(), service.sla.ServiceArea())
+	if this.services[key] == service {
+		delete(this.services, key)
+	}
+}
+
+// get returns the service registered under the key, or nil.
+func (this *inventories) get(key string) *InventoryService {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return this.services[key]
+}
+
+// keys returns the keys of the registered services in sorted order.
+func (this *inventories) keys() []string {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	keys := make([]string, 0, len(this.services))
+	for key := range this.services {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// openAPIKey returns the path of a service's document relative to OpenAPIPath.
+func openAPIKey(serviceName string, serviceArea byte) string {
+	return strconv.Itoa(int(serviceArea)) + "/" + serviceName
+}
+
+// OpenAPIHandler returns an HTTP handler serving the OpenAPI documents of the
+// activated inventory services under OpenAPIPath, for processes that run their own
+// HTTP server. The document of a single inventory is also served through its web
+// service, as the document of an l8inventory.L8InventoryOpenAPI returned by a GET
+// carrying one.
+//
+// Example:
+//
+//	mux.Handle(inventory.OpenAPIPath, inventory.OpenAPIHandler())
+//	// GET /.well-known/openapi/          -> ["0/NCache", "0/K8sCache"]
+//	// GET /.well-known/openapi/0/NCache  -> OpenAPI 3 document of NCache
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		key := strings.Trim(strings.TrimPrefix(r.URL.Path, OpenAPIPath), "/")
+		var data []byte
+		var err error
+		if key == "" {
+			data, err = json.Marshal(activated.keys())
+		} else {
+			service := activated.get(key)
+			if service == nil {
+				http.NotFound(w, r)
+				return
+			}
+			data, err = service.OpenAPI()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// OpenAPI generates an OpenAPI 3 document describing the REST endpoints of the
+// service, with schemas derived from the service item proto type. Primary key
+// fields are listed as required and in the "x-primary-keys" extension of the
+// item schema. Paths follow the Layer 8 web layer convention "/<area>/<serviceName>",
+// with the request of a GET passed as protojson in the "body" query parameter.
+func (this *InventoryService) OpenAPI() ([]byte, error) {
+	gen := &openAPIGen{schemas: make(map[string]interface{})}
+	item := this.sla.ServiceItem().(proto.Message).ProtoReflect().Descriptor()
+	gen.message(item)
+	itemSchema := gen.schemas[string(item.FullName())].(map[string]interface{})
+	keys := make([]string, 0, len(this.inventoryCenter.primaryKeyAttributes))
+	for _, attr := range this.inventoryCenter.primaryKeyAttributes {
+		if fd := fieldByGoName(item, attr); fd != nil {
+			keys = append(keys, fd.JSONName())
+		}
+	}
+	if len(keys) > 0 {
+		itemSchema["required"] = keys
+		itemSchema["x-primary-keys"] = keys
+	}
+
+	operations := make(map[string]interface{})
+	for _, ep := range this.endpoints() {
+		method := strings.ToLower(actionName(ep.action))
+		body := gen.ref(ep.body.ProtoReflect().Descriptor())
+		response := gen.ref(ep.response.ProtoReflect().Descriptor())
+		op, ok := operations[method].(map[string]interface{})
+		if !ok {
+			op = map[string]interface{}{"operationId": method + this.sla.ServiceName()}
+			operations[method] = op
+		}
+		addOneOf(op, "responses", response)
+		if ep.action == ifs.GET {
+			addOneOf(op, "parameters", body)
+			continue
+		}
+		addOneOf(op, "requestBody", body)
+	}
+	if get, ok := operations["get"].(map[string]interface{}); ok {
+		schemas := get["parameters"].([]interface{})
+		get["parameters"] = []interface{}{map[string]interface{}{
+			"name": "body", "in": "query", "required": true,
+			"description": "An L8Query, a service item with its primary key set, an L8InventoryMetrics, an L8InventoryFacets or an L8InventoryOpenAPI, in protojson format",
+			"content":     jsonContent(oneOf(schemas)),
+		}}
+	}
+	for method, op := range operations {
+		o := op.(map[string]interface{})
+		if schemas, ok := o["requestBody"].([]interface{}); ok {
+			o["requestBody"] = map[string]interface{}{"required": true, "content": jsonContent(oneOf(schemas))}
+		}
+		o["responses"] = map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK", "content": jsonContent(oneOf(o["responses"].([]interface{})))},
+		}
+		operations[method] = o
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   this.sla.ServiceName() + " inventory",
+			"version": "1.0.0",
+		},
+		"paths": map[string]interface{}{
+			"/" + openAPIKey(this.sla.ServiceName(), this.sla.ServiceArea()): operations,
+		},
+		"components": map[string]interface{}{"schemas": gen.schemas},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// openAPI returns the OpenAPI document of the inventory in response to a GET
+// carrying an l8inventory.L8InventoryOpenAPI, so it is reachable through the web
+// layer.
+func (this *InventoryService) openAPI() ifs.IElements {
+	data, err := this.OpenAPI()
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	return object.New(nil, &l8inventory.L8InventoryOpenAPI{Document: string(data)})
+}
+
+// addOneOf appends a schema to the list under name, skipping duplicates.
+func addOneOf(op map[string]interface{}, name string, schema interface{}) {
+	list, _ := op[name].([]interface{})
+	ref := schema.(map[string]interface{})["$ref"]
+	for _, existing := range list {
+		if existing.(map[string]interface{})["$ref"] == ref {
+			return
+		}
+	}
+	op[name] = append(list, schema)
+}
+
+// oneOf returns the single schema, or a oneOf schema of several.
+func oneOf(schemas []interface{}) interface{} {
+	if len(schemas) == 1 {
+		return schemas[0]
+	}
+	return map[string]interface{}{"oneOf": schemas}
+}
+
+// jsonContent returns an OpenAPI content object of the schema in JSON.
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// fieldByGoName returns the field of a message whose Go struct field has the
+// given name, or nil.
+func fieldByGoName(md protoreflect.MessageDescriptor, goName string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if strings.EqualFold(strings.ReplaceAll(string(fd.Name()), "_", ""), goName) {
+			return fd
+		}
+	}
+	return nil
+}
+
+// openAPIGen collects the component schemas of proto messages.
+type openAPIGen struct {
+	schemas map[string]interface{}
+}
+
+// ref returns a reference to the schema of a message, generating it if needed.
+func (this *openAPIGen) ref(md protoreflect.MessageDescriptor) map[string]interface{} {
+	this.message(md)
+	return map[string]interface{}{"$ref": "#/components/schemas/" + string(md.FullName())}
+}
+
+// message generates the schema of a message and of the messages it refers to.
+func (this *openAPIGen) message(md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := this.schemas[name]; ok {
+		return
+	}
+	properties := make(map[string]interface{})
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	this.schemas[name] = schema
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		switch {
+		case fd.IsMap():
+			properties[fd.JSONName()] = map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": this.field(fd.MapValue()),
+			}
+		case fd.IsList():
+			properties[fd.JSONName()] = map[string]interface{}{"type": "array", "items": this.field(fd)}
+		default:
+			properties[fd.JSONName()] = this.field(fd)
+		}
+	}
+}
+
+// field returns the schema of a single value of a field, following the protojson
+// mapping: 64-bit integers are strings and enums are their value names.
+func (this *openAPIGen) field(fd protoreflect.FieldDescriptor) interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.StringKind:
+		return map[string]interface{}{"type": "string"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names[i] = string(values.Get(i).Name())
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return this.ref(fd.Message())
+	}
+	return map[string]interface{}{}
+}
@@ -0,0 +1,429 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/saichler/l8bus/go/overlay/health"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8notify"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// AlertModelType is the ModelType of the notifications carrying alert events, so
+// receivers can tell them from change notifications.
+const AlertModelType = "InventoryAlert"
+
+// defaultRulesInterval is how often duration conditions are checked by default.
+const defaultRulesInterval = time.Second
+
+// Rule raises an alert for an element while it matches a condition, optionally
+// only once it has matched for a duration.
+type Rule struct {
+	// Name identifies the rule within the inventory and must be unique
+	Name string
+	// Condition is a GSQL where-clause predicate, e.g. "status!=1"
+	Condition string
+	// For is how long an element must keep matching before the alert is raised,
+	// zero to raise it on the first matching change
+	For time.Duration
+}
+
+// RulesConfig configures the rules of an inventory and where their alerts are
+// delivered, when passed as an SLA argument.
+//
+// Example, alerting the "alarms" service of devices that are not UP for 5 minutes:
+//
+//	sla.SetArgs(linksId, &inventory.RulesConfig{
+//	    Rules:       []*inventory.Rule{{Name: "device-down", Condition: "status!=1", For: 5 * time.Minute}},
+//	    ServiceName: "alarms",
+//	})
+type RulesConfig struct {
+	// Rules are the rules evaluated on every committed change
+	Rules []*Rule
+	// Notifier receives the alert events. If nil, they are multicast to
+	// ServiceName/ServiceArea.
+	Notifier Notifier
+	// ServiceName is the Layer 8 service alert events are multicast to when
+	// Notifier is nil
+	ServiceName string
+	// ServiceArea is the area of ServiceName
+	ServiceArea byte
+	// Interval is how often duration conditions are checked, one second by default
+	Interval time.Duration
+}
+
+// Alert is an alert raised by a rule for an element.
+type Alert struct {
+	// Rule is the name of the rule
+	Rule string
+	// Key is the composite primary key of the element
+	Key string
+	// Since is the time, in milliseconds, the element started matching the rule
+	Since int64
+	// Raised is the time, in milliseconds, the alert was raised
+	Raised int64
+	// Element is a copy of the element as of its last matching change
+	Element interface{}
+}
+
+// ruleState tracks an element matching a rule, pending until the alert is raised.
+type ruleState struct {
+	alert  *Alert
+	raised bool
+}
+
+// activeRule is a rule with its parsed condition and the elements matching it.
+type activeRule struct {
+	rule   *Rule
+	filter ifs.IQuery
+	states map[string]*ruleState
+}
+
+// alertEvent is the raise or clear event of an alert awaiting delivery.
+type alertEvent struct {
+	alert  Alert
+	raised bool
+	time   int64
+}
+
+// rulesEngine evaluates the rules of an inventory and delivers their alert events.
+type rulesEngine struct {
+	mtx      *sync.Mutex
+	rules    []*activeRule
+	notifier Notifier
+	// pending are the events awaiting delivery, in the order they occurred
+	pending []*alertEvent
+	// wake signals the delivering goroutine that events are pending
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// queue adds the event of an alert to the pending events. Must be called with the
+// engine lock held.
+func (this *rulesEngine) queue(alert *Alert, raised bool, now int64) {
+	this.pending = append(this.pending, &alertEvent{alert: *alert, raised: raised, time: now})
+	select {
+	case this.wake <- struct{}{}:
+	default:
+	}
+}
+
+// startRules creates the rules engine of the configuration and starts checking
+// its duration conditions. The rules are evaluated by the inventory center for
+// every committed write, while it holds its write lock.
+func (this *InventoryService) startRules(cfg *RulesConfig) error {
+	notifier := cfg.Notifier
+	if notifier == nil {
+		if cfg.ServiceName == "" {
+			return errors.New("rules have no notifier or service name")
+		}
+		notifier = NewServiceNotifier(this.nic, cfg.ServiceName, cfg.ServiceArea)
+	}
+	engine := &rulesEngine{mtx: &sync.Mutex{}, notifier: notifier, wake: make(chan struct{}, 1),
+		stop: make(chan struct{}), done: make(chan struct{})}
+	this.rules = engine
+	this.setCommitted(this.evaluateRules)
+	for _, rule := range cfg.Rules {
+		if err := this.AddRule(rule); err != nil {
+			this.setCommitted(nil)
+			this.rules = nil
+			return err
+		}
+	}
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultRulesInterval
+	}
+	go this.checkRules(interval)
+	return nil
+}
+
+// setCommitted sets the function the inventory center calls for every committed
+// write.
+func (this *InventoryService) setCommitted(f func(changes []*Change)) {
+	this.inventoryCenter.mtx.Lock()
+	defer this.inventoryCenter.mtx.Unlock()
+	this.inventoryCenter.committed = f
+}
+
+// AddRule starts evaluating a rule. The cached elements matching it are found
+// first, so an element already matching raises the alert of a rule without a
+// duration right away, and that of a rule with a duration once it kept matching
+// for it from now on. Returns an error if rules are not configured, the name is
+// empty or taken, or the condition does not parse.
+func (this *InventoryService) AddRule(rule *Rule) error {
+	if this.rules == nil {
+		return errors.New("rules are not configured for " + this.sla.ServiceName())
+	}
+	if rule == nil || rule.Name == "" {
+		return errors.New("rule name is required")
+	}
+	filter, err := this.sinkFilter(rule.Condition)
+	if err != nil {
+		return err
+	}
+	copied := *rule
+	center := this.inventoryCenter
+	center.mtx.Lock()
+	defer center.mtx.Unlock()
+	this.rules.mtx.Lock()
+	defer this.rules.mtx.Unlock()
+	for _, existing := range this.rules.rules {
+		if existing.rule.Name == rule.Name {
+			return errors.New("rule " + rule.Name + " already exists")
+		}
+	}
+	active := &activeRule{rule: &copied, filter: filter, states: make(map[string]*ruleState)}
+	now := time.Now().UnixMilli()
+	err = center.scanLocked(func(elem interface{}) {
+		if !filter.Match(elem) {
+			return
+		}
+		key := center.keyOf(elem)
+		state := &ruleState{alert: &Alert{Rule: copied.Name, Key: key, Since: now, Element: cloneElement(elem)}}
+		active.states[key] = state
+		if copied.For <= 0 {
+			this.raise(state, now)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	this.rules.rules = append(this.rules.rules, active)
+	return nil
+}
+
+// RemoveRule stops evaluating the named rule, clearing its raised alerts. Returns
+// false if no such rule exists.
+func (this *InventoryService) RemoveRule(name string) bool {
+	if this.rules == nil {
+		return false
+	}
+	this.rules.mtx.Lock()
+	defer this.rules.mtx.Unlock()
+	for i, active := range this.rules.rules {
+		if active.rule.Name == name {
+			this.rules.rules = append(this.rules.rules[:i], this.rules.rules[i+1:]...)
+			now := time.Now().UnixMilli()
+			for _, state := range active.states {
+				if state.raised {
+					this.rules.queue(state.alert, false, now)
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Alerts returns the raised alerts, ordered by rule and key.
+func (this *InventoryService) Alerts() []*Alert {
+	if this.rules == nil {
+		return nil
+	}
+	this.rules.mtx.Lock()
+	defer this.rules.mtx.Unlock()
+	var result []*Alert
+	for _, active := range this.rules.rules {
+		for _, state := range active.states {
+			if state.raised {
+				copied := *state.alert
+				result = append(result, &copied)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rule != result[j].Rule {
+			return result[i].Rule < result[j].Rule
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// evaluateRules updates the rule states with the committed changes, raising the
+// alerts of rules without a duration and clearing those of elements that no
+// longer match. The inventory center calls it with its write lock held, so the
+// changes are matched in the order they were applied and no write modifies an
+// element while it is matched or copied. It runs on every replica, for the writes
+// it receives in requests and those replicated to it, so each replica holds the
+// same alerts.
+func (this *InventoryService) evaluateRules(changes []*Change) {
+	now := time.Now().UnixMilli()
+	this.rules.mtx.Lock()
+	defer this.rules.mtx.Unlock()
+	for _, change := range changes {
+		if change.NoOp() {
+			continue
+		}
+		var element interface{}
+		for _, active := range this.rules.rules {
+			state := active.states[change.Key]
+			if change.New == nil || !active.filter.Match(change.New) {
+				if state != nil {
+					delete(active.states, change.Key)
+					if state.raised {
+						this.rules.queue(state.alert, false, now)
+					}
+				}
+				continue
+			}
+			if element == nil {
+				element = cloneElement(change.New)
+			}
+			if state != nil {
+				state.alert.Element = element
+				continue
+			}
+			state = &ruleState{alert: &Alert{Rule: active.rule.Name, Key: change.Key, Since: now, Element: element}}
+			active.states[change.Key] = state
+			if active.rule.For <= 0 {
+				this.raise(state, now)
+			}
+		}
+	}
+}
+
+// checkRules delivers the pending alert events as they occur and periodically
+// raises the alerts of elements that matched a rule for its duration, until the
+// engine is stopped. The events pending then are delivered before it returns.
+func (this *InventoryService) checkRules(interval time.Duration) {
+	engine := this.rules
+	defer close(engine.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-engine.stop:
+			this.deliverAlerts(engine)
+			return
+		case <-engine.wake:
+		case <-ticker.C:
+			now := time.Now().UnixMilli()
+			engine.mtx.Lock()
+			for _, active := range engine.rules {
+				for _, state := range active.states {
+					if !state.raised && now-state.alert.Since >= active.rule.For.Milliseconds() {
+						this.raise(state, now)
+					}
+				}
+			}
+			engine.mtx.Unlock()
+		}
+		this.deliverAlerts(engine)
+	}
+}
+
+// deliverAlerts sends the pending alert events, without holding the engine lock.
+// Every replica evaluates the rules, but only the one returned by alertReplica
+// sends the events; the others drop them.
+func (this *InventoryService) deliverAlerts(engine *rulesEngine) {
+	engine.mtx.Lock()
+	pending := engine.pending
+	engine.pending = nil
+	engine.mtx.Unlock()
+	if len(pending) == 0 || !this.alertReplica() {
+		return
+	}
+	for _, event := range pending {
+		this.sendAlert(engine.notifier, &event.alert, event.raised, event.time)
+	}
+}
+
+// alertReplica reports whether this replica delivers the alert events: the one
+// with the lowest UUID among the replicas of the inventory known to the health
+// service. When it leaves, the next one takes over with the same alert states.
+func (this *InventoryService) alertReplica() bool {
+	local := this.nic.Resources().SysConfig().LocalUuid
+	area := int32(this.sla.ServiceArea())
+	for _, hp := range health.Health(this.nic.Resources()).All() {
+		if hp.AUuid == "" || hp.AUuid >= local || hp.Services == nil {
+			continue
+		}
+		if areas, ok := hp.Services.ServiceToAreas[this.sla.ServiceName()]; ok && areas != nil && areas.Areas[area] {
+			return false
+		}
+	}
+	return true
+}
+
+// raise marks the alert as raised and queues its event. Must be called with the
+// engine lock held.
+func (this *InventoryService) raise(state *ruleState, now int64) {
+	state.raised = true
+	state.alert.Raised = now
+	this.rules.queue(state.alert, true, now)
+}
+
+// sendAlert delivers the raise or clear event of an alert. The event is a
+// notification with ModelType AlertModelType, the element key in ModelKey and
+// Type Post for a raise or Delete for a clear, and the UUID of the replica that
+// delivered it in Source. Its notifications hold the JSON values of "rule",
+// "state" ("raised" or "cleared") and "since", followed by the element in
+// protojson format with an empty PropertyId.
+func (this *InventoryService) sendAlert(notifier Notifier, alert *Alert, raised bool, now int64) {
+	action, nType, state := ifs.POST, l8notify.L8NotificationType_Post, "raised"
+	if !raised {
+		action, nType, state = ifs.DELETE, l8notify.L8NotificationType_Delete, "cleared"
+	}
+	rule, _ := json.Marshal(alert.Rule)
+	n := &l8notify.L8NotificationSet{
+		ServiceName: this.sla.ServiceName(),
+		ServiceArea: int32(this.sla.ServiceArea()),
+		ModelType:   AlertModelType,
+		ModelKey:    alert.Key,
+		Source:      this.nic.Resources().SysConfig().LocalUuid,
+		Type:        nType,
+		Sequence:    atomic.AddUint32(&this.sequence, 1),
+		Time:        now,
+		NotificationList: []*l8notify.L8Notification{
+			{PropertyId: "rule", NewValue: rule},
+			{PropertyId: "state", NewValue: []byte(`"` + state + `"`)},
+			{PropertyId: "since", NewValue: []byte(strconv.FormatInt(alert.Since, 10))},
+		},
+	}
+	if pb, ok := alert.Element.(proto.Message); ok {
+		if data, err := protojson.Marshal(pb); err == nil {
+			n.NotificationList = append(n.NotificationList, &l8notify.L8Notification{NewValue: data})
+		}
+	}
+	if err := notifier.Notify(action, n); err != nil {
+		this.nic.Resources().Logger().Error("Alert of rule ", alert.Rule, ": ", err.Error())
+	}
+}
+
+// closeRules stops evaluating the rules, delivers the pending alert events and
+// closes the alert notifier.
+func (this *InventoryService) closeRules() {
+	if this.rules == nil {
+		return
+	}
+	this.setCommitted(nil)
+	close(this.rules.stop)
+	<-this.rules.done
+	if err := this.rules.notifier.Close(); err != nil {
+		this.nic.Resources().Logger().Error("Failed to close the alert notifier: ", err.Error())
+	}
+	this.rules = nil
+}
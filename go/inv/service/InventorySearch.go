@@ -0,0 +1,441 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// SearchConfig enables full-text search over string fields of the service item
+// when passed as an SLA argument.
+type SearchConfig struct {
+	// Fields are the dot separated paths, by proto or JSON field name, of the
+	// indexed fields, e.g. "hostname" or "info.description". A field may be a
+	// string, a repeated string or a map with string values, in which case both
+	// the keys and the values are indexed.
+	Fields []string
+}
+
+// textIndex is an inverted index of the tokens of the searchable fields. It is
+// updated on every write and guarded by the center's write lock.
+type textIndex struct {
+	// paths are the resolved paths of the indexed fields
+	paths [][]protoreflect.FieldDescriptor
+	// postings holds the keys of the documents containing each token, as a
+	// map[string]bool per token, in token order so the tokens starting with a
+	// prefix are found without scanning the others
+	postings *trie
+	// docs holds the indexed document of every key
+	docs map[string]*textDoc
+	// tokens is the total number of tokens of all documents
+	tokens int
+}
+
+// textDoc is the indexed text of an element.
+type textDoc struct {
+	// element is the cached element
+	element interface{}
+	// values holds the token sequence of every indexed value; phrases only
+	// match within a single value
+	values [][]string
+	// length is the number of tokens of the document
+	length int
+}
+
+// searchTerm is a word, prefix or phrase of a search text.
+type searchTerm struct {
+	// tokens must appear consecutively in one value
+	tokens []string
+	// prefix is set if the last token only needs to be a prefix
+	prefix bool
+}
+
+// searchHit is an element matching a search, with its relevance score.
+type searchHit struct {
+	element interface{}
+	score   float64
+}
+
+// EnableSearch builds a text index over the given string fields of the elements
+// and keeps it up to date on every write, replacing any previous index. The
+// fields are given as described by SearchConfig.Fields.
+func (this *InventoryCenter) EnableSearch(fields ...string) error {
+	if len(fields) == 0 {
+		return errors.New("no fields to search")
+	}
+	md := this.element.(proto.Message).ProtoReflect().Descriptor()
+	index := &textIndex{postings: &trie{}, docs: make(map[string]*textDoc)}
+	for _, field := range fields {
+		path, err := stringFieldPath(md, field)
+		if err != nil {
+			return err
+		}
+		index.paths = append(index.paths, path)
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	err := this.scanLocked(func(elem interface{}) {
+		index.update(this.keyOf(elem), elem)
+	})
+	if err != nil {
+		return err
+	}
+	this.search = index
+	return nil
+}
+
+// stringFieldPath resolves the path of a string, repeated string or string valued
+// map field.
+func stringFieldPath(md protoreflect.MessageDescriptor, field string) ([]protoreflect.FieldDescriptor, error) {
+	path, err := fieldPath(md, field)
+	if err != nil {
+		return nil, err
+	}
+	fd := path[len(path)-1]
+	if fd.IsMap() {
+		fd = fd.MapValue()
+	}
+	if fd.Kind() != protoreflect.StringKind {
+		return nil, errors.New("field " + field + " is not a string field")
+	}
+	return path, nil
+}
+
+// update replaces the document of the key with the tokens of the element, or
+// removes it if the element is nil.
+func (this *textIndex) update(key string, elem interface{}) {
+	if doc, ok := this.docs[key]; ok {
+		for _, value := range doc.values {
+			for _, token := range value {
+				if keys := this.keys(token); keys != nil {
+					delete(keys, key)
+					if len(keys) == 0 {
+						this.postings.delete(token)
+					}
+				}
+			}
+		}
+		this.tokens -= doc.length
+		delete(this.docs, key)
+	}
+	pb, ok := elem.(proto.Message)
+	if !ok || key == "" {
+		return
+	}
+	doc := &textDoc{element: elem}
+	for _, path := range this.paths {
+		for _, text := range searchValues(pb.ProtoReflect(), path) {
+			if tokens := tokenize(text); len(tokens) > 0 {
+				doc.values = append(doc.values, tokens)
+				doc.length += len(tokens)
+			}
+		}
+	}
+	for _, value := range doc.values {
+		for _, token := range value {
+			keys := this.keys(token)
+			if keys == nil {
+				keys = make(map[string]bool)
+				this.postings.put(token, keys)
+			}
+			keys[key] = true
+		}
+	}
+	this.tokens += doc.length
+	this.docs[key] = doc
+}
+
+// searchValues returns the strings of the field at the path: its value, its
+// items or its map keys and values.
+func searchValues(m protoreflect.Message, path []protoreflect.FieldDescriptor) []string {
+	for _, fd := range path[:len(path)-1] {
+		if !m.Has(fd) {
+			return nil
+		}
+		m = m.Get(fd).Message()
+	}
+	fd := path[len(path)-1]
+	if !m.Has(fd) {
+		return nil
+	}
+	value := m.Get(fd)
+	switch {
+	case fd.IsList():
+		list := value.List()
+		result := make([]string, list.Len())
+		for i := range result {
+			result[i] = list.Get(i).String()
+		}
+		return result
+	case fd.IsMap():
+		var result []string
+		value.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			result = append(result, k.String(), v.String())
+			return true
+		})
+		return result
+	}
+	return []string{value.String()}
+}
+
+// tokenize splits a text into lower case tokens of letters and digits, so
+// "core-sw1.dc1" is indexed as "core", "sw1" and "dc1".
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// parseSearch parses a search text into its terms. Words are separated by
+// spaces, a word ending with "*" matches tokens starting with it and a double
+// quoted phrase matches its words appearing in order in the same field.
+func parseSearch(text string) ([]*searchTerm, error) {
+	var terms []*searchTerm
+	add := func(text string, prefix bool) {
+		if tokens := tokenize(text); len(tokens) > 0 {
+			terms = append(terms, &searchTerm{tokens: tokens, prefix: prefix})
+		}
+	}
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				return nil, errors.New("unterminated phrase in search " + text)
+			}
+			add(text[1:end+1], false)
+			text = text[end+2:]
+			continue
+		}
+		end := strings.IndexFunc(text, unicode.IsSpace)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		add(word, strings.HasSuffix(word, "*"))
+		text = text[end:]
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("search has no words")
+	}
+	return terms, nil
+}
+
+// keys returns the keys of the documents containing the token, nil if none.
+func (this *textIndex) keys(token string) map[string]bool {
+	keys, _ := this.postings.get(token)
+	result, _ := keys.(map[string]bool)
+	return result
+}
+
+// candidates returns the keys of the documents that may contain the term. A
+// prefix term visits only the tokens starting with its prefix.
+func (this *textIndex) candidates(term *searchTerm) map[string]bool {
+	if len(term.tokens) > 1 || !term.prefix {
+		return this.keys(term.tokens[0])
+	}
+	result := make(map[string]bool)
+	this.postings.walkPrefix(term.tokens[0], func(token string, keys interface{}) bool {
+		for key := range keys.(map[string]bool) {
+			result[key] = true
+		}
+		return true
+	})
+	return result
+}
+
+// count returns the number of times the term appears in the document.
+func (this *searchTerm) count(doc *textDoc) int {
+	count := 0
+	last := len(this.tokens) - 1
+	for _, value := range doc.values {
+	next:
+		for i := 0; i+last < len(value); i++ {
+			for j, token := range this.tokens {
+				if value[i+j] != token && !(j == last && this.prefix && strings.HasPrefix(value[i+j], token)) {
+					continue next
+				}
+			}
+			count++
+		}
+	}
+	return count
+}
+
+// find returns the documents containing every term, most relevant first. The
+// relevance is the BM25 score of the terms, which favors rare terms and terms
+// that appear often in short documents.
+func (this *textIndex) find(terms []*searchTerm) []*searchHit {
+	const k1, b = 1.2, 0.75
+	if len(this.docs) == 0 {
+		return nil
+	}
+	candidates := make([]map[string]bool, len(terms))
+	smallest := 0
+	for i, term := range terms {
+		candidates[i] = this.candidates(term)
+		if len(candidates[i]) < len(candidates[smallest]) {
+			smallest = i
+		}
+	}
+	n := float64(len(this.docs))
+	avgLength := math.Max(float64(this.tokens)/n, 1)
+	var hits []*searchHit
+	for key := range candidates[smallest] {
+		doc := this.docs[key]
+		score := 0.0
+		for i, term := range terms {
+			tf := float64(term.count(doc))
+			if tf == 0 {
+				score = -1
+				break
+			}
+			df := float64(len(candidates[i]))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(doc.length)/avgLength))
+		}
+		if score >= 0 {
+			hits = append(hits, &searchHit{element: doc.element, score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+	return hits
+}
+
+// Search returns the elements matching a search text, most relevant first. The
+// text holds words, which must all appear in the indexed fields; a word ending
+// with "*" matches any word starting with it, and a double quoted phrase matches
+// its words in order within one field. If query is not nil, only the elements
+// matching it are returned, paged by its page and limit. The options, nil for
+// the defaults, apply to this search only. Returns an error if search is not
+// enabled or the text is invalid.
+//
+// Example:
+//
+//	elems, _, err := center.Search(`"core switch" dc1*`, nil, nil)
+func (this *InventoryCenter) Search(text string, query ifs.IQuery, opts *QueryOptions) ([]interface{}, *l8api.L8MetaData, error) {
+	terms, err := parseSearch(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	this.stats.queries.Add(1)
+	this.mtx.Lock()
+	if this.search == nil {
+		this.mtx.Unlock()
+		return nil, nil, errors.New("search is not enabled for " + this.serviceName)
+	}
+	hits := this.search.find(terms)
+	elems := make([]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		if query == nil || query.Match(hit.element) {
+			elems = append(elems, hit.element)
+		}
+	}
+	elems = this.withoutPlaceholders(elems, opts)
+	this.mtx.Unlock()
+
+	total := len(elems)
+	if query != nil && query.Limit() > 0 {
+		start := int(query.Page() * query.Limit())
+		end := start + int(query.Limit())
+		elems = elems[min(start, total):min(end, total)]
+	}
+	return elems, &l8api.L8MetaData{KeyCount: &l8api.L8Count{Counts: map[string]int32{"Total": int32(total)}}}, nil
+}
+
+// searchClause matches the search clause of a GSQL where condition, e.g.
+// search('"core switch" dc1*'), with the search text single or double quoted.
+var searchClause = regexp.MustCompile(`(?i)\bsearch\s*\(\s*('[^']*'|"(?:[^"\\]|\\.)*")\s*\)`)
+
+// gsqlOr matches the or operator of a GSQL condition, outside of quoted values.
+var gsqlOr = regexp.MustCompile(`(?i)(^|[\s)])or([\s(]|$)`)
+
+// gsqlQuoted matches the quoted values of a GSQL query.
+var gsqlQuoted = regexp.MustCompile(`'[^']*'|"(?:[^"\\]|\\.)*"`)
+
+// gsqlConditionEnd matches the GSQL keywords that may follow a where condition.
+var gsqlConditionEnd = regexp.MustCompile(`(?i)^(sort-by|descending|limit|page|match-case)\b`)
+
+// splitSearch removes the search clause from a GSQL query, returning the search
+// text and the remaining query. The clause must be combined with the other
+// conditions with "and", and the query may not use "or" anywhere, as in
+// "search('x') and a=1 or b=2" the search would only restrict one alternative.
+// Returns ok false if the query has no search clause.
+func splitSearch(gsql string) (text, rest string, ok bool, err error) {
+	loc := searchClause.FindStringSubmatchIndex(gsql)
+	if loc == nil {
+		return "", gsql, false, nil
+	}
+	quoted := gsql[loc[2]:loc[3]]
+	text = quoted[1 : len(quoted)-1]
+	if quoted[0] == '"' {
+		text = strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(text)
+	}
+	before := strings.TrimSpace(gsql[:loc[0]])
+	after := strings.TrimSpace(gsql[loc[1]:])
+	lower := strings.ToLower(before)
+	if gsqlOr.MatchString(gsqlQuoted.ReplaceAllString(before+" "+after, "''")) {
+		return "", "", true, errors.New("search must be combined with other conditions using and, without or")
+	}
+	switch {
+	case strings.HasSuffix(lower, " and"):
+		before = strings.TrimSpace(before[:len(before)-3])
+	case strings.HasSuffix(lower, " where") && len(after) >= 4 && strings.EqualFold(after[:4], "and "):
+		after = strings.TrimSpace(after[4:])
+	case strings.HasSuffix(lower, " where") && (after == "" || gsqlConditionEnd.MatchString(after)):
+		before = strings.TrimSpace(before[:len(before)-5])
+	default:
+		return "", "", true, errors.New("search must be combined with other conditions using and")
+	}
+	if searchClause.MatchString(after) || searchClause.MatchString(before) {
+		return "", "", true, errors.New("a query may only have one search clause")
+	}
+	return text, strings.TrimSpace(before + " " + after), true, nil
+}
+
+// searchRequest serves a GSQL query with a search clause from the text index,
+// applying its other conditions and the options to the matching elements. Returns
+// ok false if the request is not such a query.
+func (this *InventoryCenter) searchRequest(pb ifs.IElements, opts *QueryOptions) (elems []interface{}, stats *l8api.L8MetaData, ok bool, err error) {
+	pquery, isQuery := pb.Element().(*l8api.L8Query)
+	if !isQuery {
+		return nil, nil, false, nil
+	}
+	text, rest, ok, err := splitSearch(pquery.Text)
+	if !ok || err != nil {
+		return nil, nil, ok, err
+	}
+	q, err := object.NewQuery(rest, this.resources)
+	if err != nil {
+		return nil, nil, true, err
+	}
+	query, err := q.Query(this.resources)
+	if err != nil {
+		return nil, nil, true, err
+	}
+	elems, stats, err = this.Search(text, query, opts)
+	return elems, stats, true, err
+}
@@ -0,0 +1,364 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// defaultSeriesCapacity is the number of samples kept per key and field by default.
+const defaultSeriesCapacity = 1024
+
+// SeriesConfig enables recording numeric fields of the service item as time
+// series when passed as an SLA argument. A sample of every field is recorded on
+// each Post, Put and Patch of an element, and its series are dropped when it is
+// deleted.
+type SeriesConfig struct {
+	// Fields are the dot separated paths, by proto or JSON field name, of the
+	// recorded numeric fields, e.g. "cpu" or "stats.rx_bytes"
+	Fields []string
+	// Capacity is the number of samples kept per key and field, the oldest
+	// being overwritten. Series grow as samples arrive, up to it. Defaults to 1024.
+	Capacity int
+	// Retention drops samples older than it, on write and from the samples
+	// returned by Series, zero to keep Capacity samples regardless of their age
+	Retention time.Duration
+}
+
+// Aggregation combines the samples of a downsampling step into one.
+type Aggregation int
+
+const (
+	// AggregateMean averages the samples
+	AggregateMean Aggregation = iota
+	// AggregateMin takes the smallest sample
+	AggregateMin
+	// AggregateMax takes the largest sample
+	AggregateMax
+	// AggregateLast takes the latest sample
+	AggregateLast
+)
+
+// SeriesQuery selects the samples of a field.
+type SeriesQuery struct {
+	// Field is the recorded field, as given in SeriesConfig.Fields
+	Field string
+	// From and To bound the sample times, inclusive; zero for unbounded
+	From, To time.Time
+	// Step downsamples the series into one sample per step, aligned to
+	// multiples of the step since the epoch. Zero returns the raw samples.
+	Step time.Duration
+	// Aggregate combines the samples of a step
+	Aggregate Aggregation
+}
+
+// Sample is a value of a field at a time.
+type Sample struct {
+	// Time is the time the value was recorded, or the start of its step, in
+	// milliseconds
+	Time int64
+	// Value is the field value
+	Value float64
+}
+
+// sampleRing is a bounded series of samples, overwriting the oldest when full.
+// Its buffer grows as samples are added, up to the capacity.
+type sampleRing struct {
+	samples []Sample
+	// capacity is the largest number of samples held
+	capacity int
+	// next is the position of the next sample, len(samples) once the buffer
+	// must either grow or wrap around
+	next int
+	// size is the number of samples held
+	size int
+}
+
+// add appends a sample, overwriting the oldest if the ring is full. Returns the
+// overwritten sample and whether there was one.
+func (this *sampleRing) add(sample Sample) (Sample, bool) {
+	if this.next == len(this.samples) {
+		if len(this.samples) < this.capacity {
+			this.samples = append(this.samples, sample)
+			this.next = len(this.samples)
+			this.size++
+			return Sample{}, false
+		}
+		this.next = 0
+	}
+	overwritten, full := this.samples[this.next], this.size == len(this.samples)
+	this.samples[this.next] = sample
+	this.next++
+	if !full {
+		this.size++
+	}
+	return overwritten, full
+}
+
+// pop removes the latest sample, putting back the sample it overwrote, if any.
+func (this *sampleRing) pop(overwritten Sample, full bool) {
+	if this.size == 0 {
+		return
+	}
+	if this.next == 0 {
+		this.next = len(this.samples)
+	}
+	this.next--
+	if full {
+		this.samples[this.next] = overwritten
+		return
+	}
+	this.size--
+}
+
+// trim drops the samples recorded before the time, releasing the buffer once
+// none are left.
+func (this *sampleRing) trim(before int64) {
+	for this.size > 0 && this.at(0).Time < before {
+		this.size--
+	}
+	if this.size == 0 {
+		this.samples = nil
+		this.next = 0
+	}
+}
+
+// at returns the i-th oldest sample.
+func (this *sampleRing) at(i int) Sample {
+	return this.samples[(this.next-this.size+i+len(this.samples))%len(this.samples)]
+}
+
+// seriesStore holds the series of the recorded fields of every key. It is guarded
+// by the center's write lock.
+type seriesStore struct {
+	cfg    *SeriesConfig
+	paths  map[string][]protoreflect.FieldDescriptor
+	series map[string]map[string]*sampleRing
+}
+
+// addedSample is a sample added to a ring, with the sample it overwrote.
+type addedSample struct {
+	ring        *sampleRing
+	overwritten Sample
+	full        bool
+}
+
+// record adds a sample of every recorded field of the element. Returns the samples
+// added, so they can be removed again.
+func (this *seriesStore) record(key string, elem interface{}, now int64) []addedSample {
+	pb, ok := elem.(proto.Message)
+	if !ok {
+		return nil
+	}
+	fields := this.series[key]
+	if fields == nil {
+		fields = make(map[string]*sampleRing)
+		this.series[key] = fields
+	}
+	var added []addedSample
+	for field, path := range this.paths {
+		value, ok := numericValue(pb.ProtoReflect(), path)
+		if !ok {
+			continue
+		}
+		ring := fields[field]
+		if ring == nil {
+			ring = &sampleRing{capacity: this.cfg.Capacity}
+			fields[field] = ring
+		}
+		if this.cfg.Retention > 0 {
+			ring.trim(now - this.cfg.Retention.Milliseconds())
+		}
+		overwritten, full := ring.add(Sample{Time: now, Value: value})
+		added = append(added, addedSample{ring: ring, overwritten: overwritten, full: full})
+	}
+	return added
+}
+
+// numericValue returns the value of the numeric field at the path. Returns false
+// if a message on the path is not set.
+func numericValue(m protoreflect.Message, path []protoreflect.FieldDescriptor) (float64, bool) {
+	for _, fd := range path[:len(path)-1] {
+		if !m.Has(fd) {
+			return 0, false
+		}
+		m = m.Get(fd).Message()
+	}
+	fd := path[len(path)-1]
+	value := m.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return float64(value.Int()), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(value.Uint()), true
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+// isNumericField reports whether a field is a singular integer or floating point field.
+func isNumericField(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() || fd.IsMap() {
+		return false
+	}
+	switch fd.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.FloatKind, protoreflect.DoubleKind:
+		return true
+	}
+	return false
+}
+
+// EnableSeries starts recording the numeric fields of the configuration as time
+// series, replacing any previous recording and its samples.
+func (this *InventoryCenter) EnableSeries(cfg *SeriesConfig) error {
+	if cfg == nil || len(cfg.Fields) == 0 {
+		return errors.New("no fields to record")
+	}
+	copied := *cfg
+	if copied.Capacity <= 0 {
+		copied.Capacity = defaultSeriesCapacity
+	}
+	store := &seriesStore{cfg: &copied, paths: make(map[string][]protoreflect.FieldDescriptor),
+		series: make(map[string]map[string]*sampleRing)}
+	md := this.element.(proto.Message).ProtoReflect().Descriptor()
+	for _, field := range cfg.Fields {
+		path, err := fieldPath(md, field)
+		if err != nil {
+			return err
+		}
+		if !isNumericField(path[len(path)-1]) {
+			return errors.New("field " + field + " is not a numeric field")
+		}
+		store.paths[field] = path
+	}
+	this.mtx.Lock()
+	this.series = store
+	this.mtx.Unlock()
+	return nil
+}
+
+// recordSeries records the samples of the element written under the key, or drops
+// its series if it was deleted. Returns a function undoing it, nil if there is
+// nothing to undo. Samples dropped for their age are not restored. Must be called
+// with the write lock held.
+func (this *InventoryCenter) recordSeries(key string, elem interface{}, now int64) func() {
+	if this.series == nil {
+		return nil
+	}
+	store := this.series
+	if elem == nil {
+		dropped := store.series[key]
+		if dropped == nil {
+			return nil
+		}
+		delete(store.series, key)
+		return func() {
+			store.series[key] = dropped
+		}
+	}
+	added := store.record(key, elem, now)
+	if len(added) == 0 {
+		return nil
+	}
+	return func() {
+		for _, sample := range added {
+			sample.ring.pop(sample.overwritten, sample.full)
+		}
+	}
+}
+
+// Series returns the samples of a recorded field of the element with the same
+// primary key, oldest first, within the query's time range and downsampled to its
+// step. Samples older than SeriesConfig.Retention are left out. Returns an error if
+// the field is not recorded.
+//
+// Example:
+//
+//	samples, err := center.Series(&Device{Id: "sw1"}, &inventory.SeriesQuery{Field: "cpu",
+//	    From: time.Now().Add(-time.Hour), Step: time.Minute, Aggregate: inventory.AggregateMax})
+func (this *InventoryCenter) Series(elem interface{}, query *SeriesQuery) ([]*Sample, error) {
+	if query == nil {
+		return nil, errors.New("missing series query")
+	}
+	from, to := int64(math.MinInt64), int64(math.MaxInt64)
+	if !query.From.IsZero() {
+		from = query.From.UnixMilli()
+	}
+	if !query.To.IsZero() {
+		to = query.To.UnixMilli()
+	}
+	step := query.Step.Milliseconds()
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.series == nil || this.series.paths[query.Field] == nil {
+		return nil, errors.New("field " + query.Field + " is not recorded")
+	}
+	if retention := this.series.cfg.Retention; retention > 0 {
+		if oldest := time.Now().UnixMilli() - retention.Milliseconds(); oldest > from {
+			from = oldest
+		}
+	}
+	ring := this.series.series[this.keyOf(elem)][query.Field]
+	if ring == nil {
+		return nil, nil
+	}
+	var result []*Sample
+	count := 0
+	for i := 0; i < ring.size; i++ {
+		sample := ring.at(i)
+		if sample.Time < from || sample.Time > to {
+			continue
+		}
+		if step <= 0 {
+			result = append(result, &Sample{Time: sample.Time, Value: sample.Value})
+			continue
+		}
+		bucket := sample.Time - ((sample.Time%step)+step)%step
+		last := len(result) - 1
+		if last < 0 || result[last].Time != bucket {
+			if last >= 0 && query.Aggregate == AggregateMean {
+				result[last].Value /= float64(count)
+			}
+			result = append(result, &Sample{Time: bucket, Value: sample.Value})
+			count = 1
+			continue
+		}
+		count++
+		switch query.Aggregate {
+		case AggregateMean:
+			result[last].Value += sample.Value
+		case AggregateMin:
+			result[last].Value = math.Min(result[last].Value, sample.Value)
+		case AggregateMax:
+			result[last].Value = math.Max(result[last].Value, sample.Value)
+		case AggregateLast:
+			result[last].Value = sample.Value
+		}
+	}
+	if step > 0 && len(result) > 0 && query.Aggregate == AggregateMean {
+		result[len(result)-1].Value /= float64(count)
+	}
+	return result, nil
+}
@@ -14,21 +14,23 @@
 package inventory
 
 import (
-	"fmt"
 	"reflect"
-	"time"
+	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/saichler/l8inventory/go/types/l8inventory"
 	"github.com/saichler/l8pollaris/go/pollaris/targets"
 	"github.com/saichler/l8srlz/go/serialize/object"
 	"github.com/saichler/l8types/go/ifs"
 	"github.com/saichler/l8types/go/types/l8api"
 	"github.com/saichler/l8types/go/types/l8notify"
-	"github.com/saichler/l8types/go/types/l8reflect"
-	"github.com/saichler/l8utils/go/utils/aggregator"
 	"github.com/saichler/l8utils/go/utils/web"
 	"google.golang.org/protobuf/proto"
 )
 
+// WsServiceName and WsServiceArea address the WebSocket notification service the
+// default notifier multicasts to.
 const (
 	WsServiceName = "websock"
 	WsServiceArea = byte(0)
@@ -46,8 +48,24 @@ type InventoryService struct {
 	// inventoryCenter is the core inventory management engine
 	inventoryCenter *InventoryCenter
 	// nic is the virtual network interface for this service
-	nic     ifs.IVNic
-	agg     *aggregator.Aggregator
+	nic ifs.IVNic
+	// sinks are the destinations changes are forwarded to, each with its own forwarder
+	sinks []*sinkRoute
+	// sinksMtx guards sinks against runtime additions and removals
+	sinksMtx *sync.RWMutex
+	// notifiers are the targets change notifications are sent to
+	notifiers []*notifierRoute
+	// notifiersMtx guards notifiers against runtime additions and removals
+	notifiersMtx *sync.RWMutex
+	// limiter debounces and rate limits the notifications
+	limiter *notifyLimiter
+	// notifyCfg holds the content settings of change notifications
+	notifyCfg atomic.Pointer[NotifyConfig]
+	// sequence numbers the notifications sent by this service
+	sequence uint32
+	// rules evaluates the alerting rules, nil if none are configured
+	rules *rulesEngine
+	// linksId is the pollaris links identifier of the persistence service
 	linksId string
 	// sla contains the service level agreement configuration
 	sla *ifs.ServiceLevelAgreement
@@ -81,74 +99,156 @@ func Activate(linksId string, serviceItem, serviceItemList interface{}, vnic ifs
 // when the service is activated.
 //
 // If the SLA contains a service link argument, the service will automatically forward
-// operations to the linked downstream service (e.g., for persistence).
+// operations to the linked downstream service (e.g., for persistence) through the
+// "persist" sink. An optional *ForwardConfig argument tunes the persist sink batches,
+// any *SinkConfig arguments add further sinks, and an optional *NotifyConfig sets
+// the content of change notifications. Notifications are sent to the targets of
+// the *NotifierConfig arguments, or to the WebSocket service if there are none. An
+// optional *SearchConfig enables full-text search over the given fields and an
+// optional *FuzzyConfig enables typo-tolerant lookup on its fields. An optional
+// *SeriesConfig records numeric fields as time series, an optional *RulesConfig
+// raises alerts on the committed changes and an optional *ChangeConfig trims the
+// per-write bookkeeping.
 //
 // Returns nil on success, or an error if initialization fails.
 func (this *InventoryService) Activate(sla *ifs.ServiceLevelAgreement, vnic ifs.IVNic) error {
 	this.sla = sla
+	this.nic = vnic
+	this.sinksMtx = &sync.RWMutex{}
+	this.notifiersMtx = &sync.RWMutex{}
+	this.SetNotifyConfig(nil)
 	vnic.Resources().Logger().Debug("Activated Inventory on ", sla.ServiceName(), " area ", sla.ServiceArea())
 	this.inventoryCenter = newInventoryCenter(sla, vnic)
-	if len(sla.Args()) == 1 {
-		this.linksId = sla.Args()[0].(string)
-		this.nic = vnic
-		this.agg = aggregator.NewAggregator(vnic, 5, 30)
+	this.limiter = newNotifyLimiter(this.notifyCfg.Load, this.sendNotifications, this.sendBulk,
+		this.inventoryCenter.copyOf)
+	var cfg *ForwardConfig
+	var sinks []*SinkConfig
+	var notifiers []*NotifierConfig
+	var search *SearchConfig
+	var fuzzy *FuzzyConfig
+	var series *SeriesConfig
+	var rules *RulesConfig
+	for _, arg := range sla.Args() {
+		switch v := arg.(type) {
+		case string:
+			this.linksId = v
+		case *ForwardConfig:
+			cfg = v
+		case *SinkConfig:
+			sinks = append(sinks, v)
+		case *NotifierConfig:
+			notifiers = append(notifiers, v)
+		case *NotifyConfig:
+			this.SetNotifyConfig(v)
+		case *SearchConfig:
+			search = v
+		case *FuzzyConfig:
+			fuzzy = v
+		case *SeriesConfig:
+			series = v
+		case *RulesConfig:
+			rules = v
+		case *ChangeConfig:
+			this.inventoryCenter.SetChangeConfig(v)
+		}
+	}
+	if series != nil {
+		if err := this.inventoryCenter.EnableSeries(series); err != nil {
+			return err
+		}
+	}
+	if fuzzy != nil {
+		if err := this.inventoryCenter.EnableFuzzy(fuzzy.Fields...); err != nil {
+			return err
+		}
+	}
+	if search != nil {
+		if err := this.inventoryCenter.EnableSearch(search.Fields...); err != nil {
+			return err
+		}
+	}
+	if this.linksId != "" {
+		err := this.AddSink(&SinkConfig{Name: PersistSinkName, Sink: &linkSink{nic: vnic, linksId: this.linksId}, Forward: cfg})
+		if err != nil {
+			return err
+		}
+	}
+	for _, sink := range sinks {
+		if err := this.AddSink(sink); err != nil {
+			return err
+		}
+	}
+	if len(notifiers) == 0 {
+		notifiers = append(notifiers, WsNotifier())
+	}
+	for _, notifier := range notifiers {
+		if err := this.AddNotifier(notifier); err != nil {
+			return err
+		}
+	}
+	if rules != nil {
+		if err := this.startRules(rules); err != nil {
+			return err
+		}
 	}
 	vnic.Resources().Registry().Register(&l8api.L8Query{})
+	vnic.Resources().Registry().Register(&l8inventory.L8InventoryTransaction{})
+	vnic.Resources().Registry().Register(&l8inventory.L8InventoryMetrics{})
+	vnic.Resources().Registry().Register(&l8inventory.L8InventoryFacets{})
+	vnic.Resources().Registry().Register(&l8inventory.L8InventoryOpenAPI{})
+	activated.add(this)
 
 	return nil
 }
 
-// notifyWs multicasts an L8NotificationSet to the WebSocket notification service
-// for each element, so connected clients receive real-time change notifications.
-func (this *InventoryService) notifyWs(elements ifs.IElements, action ifs.Action, vnic ifs.IVNic) {
-	if vnic == nil {
+// SetForwardConfig replaces the forwarding configuration of the persist sink at
+// runtime. It has no effect if the service was activated without a service link.
+func (this *InventoryService) SetForwardConfig(cfg *ForwardConfig) {
+	this.SetSinkForwardConfig(PersistSinkName, cfg)
+}
+
+// ForwardConfig returns the forwarding configuration in effect for the persist
+// sink, or nil if the service was activated without a service link.
+func (this *InventoryService) ForwardConfig() *ForwardConfig {
+	return this.SinkForwardConfig(PersistSinkName)
+}
+
+// publish forwards to the sinks and notifies the elements whose write changed the cache.
+// Writes that left the cached element byte-identical are skipped, so pollers
+// re-sending unchanged state generate no downstream traffic.
+func (this *InventoryService) publish(changes []*Change) {
+	this.publishChanges(changes, false)
+}
+
+// publishChanges forwards and notifies the changes. If tx is set, the changes are
+// those of a transaction: each sink receives them as one unit and each notifier
+// as one batch.
+func (this *InventoryService) publishChanges(changes []*Change, tx bool) {
+	if len(changedElements(changes)) == 0 {
 		return
 	}
-	modelType := reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name()
-	pkField := ""
-	if len(this.sla.PrimaryKeys()) > 0 {
-		pkField = this.sla.PrimaryKeys()[0]
+	this.fanOut(changes, tx)
+	if tx {
+		this.notifyBatch(changes)
+	} else {
+		this.notify(changes)
 	}
+}
 
-	var nType l8notify.L8NotificationType
+// notificationType maps an action to its notification type. Returns false for
+// actions that are not notified.
+func notificationType(action ifs.Action) (l8notify.L8NotificationType, bool) {
 	switch action {
 	case ifs.POST:
-		nType = l8notify.L8NotificationType_Post
+		return l8notify.L8NotificationType_Post, true
 	case ifs.PUT:
-		nType = l8notify.L8NotificationType_Put
+		return l8notify.L8NotificationType_Put, true
 	case ifs.PATCH:
-		nType = l8notify.L8NotificationType_Patch
+		return l8notify.L8NotificationType_Patch, true
 	case ifs.DELETE:
-		nType = l8notify.L8NotificationType_Delete
-	default:
-		return
-	}
-
-	for _, elem := range elements.Elements() {
-		if elem == nil {
-			continue
-		}
-		pkValue := ""
-		if pkField != "" {
-			v := reflect.ValueOf(elem)
-			if v.Kind() == reflect.Ptr {
-				v = v.Elem()
-			}
-			f := v.FieldByName(pkField)
-			if f.IsValid() {
-				pkValue = fmt.Sprint(f.Interface())
-			}
-		}
-		n := &l8notify.L8NotificationSet{
-			ServiceName: this.sla.ServiceName(),
-			ServiceArea: int32(this.sla.ServiceArea()),
-			ModelType:   modelType,
-			ModelKey:    pkValue,
-			Type:        nType,
-			Time:        time.Now().UnixMilli(),
-		}
-		vnic.Multicast(WsServiceName, WsServiceArea, action, n)
+		return l8notify.L8NotificationType_Delete, true
 	}
+	return 0, false
 }
 
 // DeActivate cleans up resources when the service is deactivated. This method is
@@ -156,23 +256,27 @@ func (this *InventoryService) notifyWs(elements ifs.IElements, action ifs.Action
 //
 // Returns nil on success.
 func (this *InventoryService) DeActivate() error {
+	activated.remove(this)
+	this.closeRules()
+	this.closeSinks()
+	this.closeNotifiers()
 	this.inventoryCenter = nil
 	return nil
 }
 
 // Post handles POST requests to add new inventory items. It stores the elements
 // in the local cache and optionally forwards the operation to a linked downstream
-// service if configured.
+// service if configured. A request carrying an l8inventory.L8InventoryTransaction
+// applies the transaction instead.
 //
 // Returns an empty elements container of the service item list type.
 func (this *InventoryService) Post(elements ifs.IElements, vnic ifs.IVNic) ifs.IElements {
-	this.inventoryCenter.Post(elements)
+	if pb, ok := elements.Element().(*l8inventory.L8InventoryTransaction); ok {
+		return this.transaction(pb)
+	}
+	changes := this.inventoryCenter.Post(elements)
 	if !elements.Notification() {
-		if this.agg != nil {
-			pServiceName, pServiceArea := targets.Links.Persist(this.linksId)
-			this.agg.AddElement(elements.Elements(), ifs.Leader, "", pServiceName, pServiceArea, ifs.POST)
-		}
-		this.notifyWs(elements, ifs.POST, vnic)
+		this.publish(changes)
 	}
 	return object.New(nil, this.sla.ServiceItemList())
 }
@@ -183,13 +287,9 @@ func (this *InventoryService) Post(elements ifs.IElements, vnic ifs.IVNic) ifs.I
 //
 // Returns an empty elements container of the service item list type.
 func (this *InventoryService) Put(elements ifs.IElements, vnic ifs.IVNic) ifs.IElements {
-	this.inventoryCenter.Put(elements)
+	changes := this.inventoryCenter.Put(elements)
 	if !elements.Notification() {
-		if this.agg != nil {
-			pServiceName, pServiceArea := targets.Links.Persist(this.linksId)
-			this.agg.AddElement(elements.Elements(), ifs.Leader, "", pServiceName, pServiceArea, ifs.PUT)
-		}
-		this.notifyWs(elements, ifs.PUT, vnic)
+		this.publish(changes)
 	}
 	return object.New(nil, this.sla.ServiceItemList())
 }
@@ -200,13 +300,9 @@ func (this *InventoryService) Put(elements ifs.IElements, vnic ifs.IVNic) ifs.IE
 //
 // Returns an empty elements container of the service item list type.
 func (this *InventoryService) Patch(elements ifs.IElements, vnic ifs.IVNic) ifs.IElements {
-	this.inventoryCenter.Patch(elements)
+	changes := this.inventoryCenter.Patch(elements)
 	if !elements.Notification() {
-		if this.agg != nil {
-			pServiceName, pServiceArea := targets.Links.Persist(this.linksId)
-			this.agg.AddElement(elements.Elements(), ifs.Leader, "", pServiceName, pServiceArea, ifs.PATCH)
-		}
-		this.notifyWs(elements, ifs.PATCH, vnic)
+		this.publish(changes)
 	}
 	return object.New(nil, this.sla.ServiceItemList())
 }
@@ -217,13 +313,9 @@ func (this *InventoryService) Patch(elements ifs.IElements, vnic ifs.IVNic) ifs.
 //
 // Returns an empty elements container of the service item list type.
 func (this *InventoryService) Delete(elements ifs.IElements, vnic ifs.IVNic) ifs.IElements {
-	this.inventoryCenter.Delete(elements)
+	changes := this.inventoryCenter.Delete(elements)
 	if !elements.Notification() {
-		if this.agg != nil {
-			pServiceName, pServiceArea := targets.Links.Persist(this.linksId)
-			this.agg.AddElement(elements.Elements(), ifs.Leader, "", pServiceName, pServiceArea, ifs.DELETE)
-		}
-		this.notifyWs(elements, ifs.DELETE, vnic)
+		this.publish(changes)
 	}
 	return object.New(nil, this.sla.ServiceItemList())
 }
@@ -232,30 +324,86 @@ func (this *InventoryService) Delete(elements ifs.IElements, vnic ifs.IVNic) ifs
 //  1. Single element lookup: If the request contains an element of the service item
 //     type, it performs a primary key lookup and returns the matching element.
 //  2. Query-based retrieval: If the request contains a query, it executes the query
-//     and returns matching elements with pagination and metadata.
+//     and returns matching elements with pagination and metadata. A query with a
+//     search('...') clause is served from the text index, ranked by relevance,
+//     and a query with the without-placeholders keyword leaves placeholders out.
+//
+// A request carrying an l8inventory.L8InventoryMetrics returns the metrics of the
+// node serving it instead, in the Prometheus text format, one carrying an
+// l8inventory.L8InventoryFacets returns the metadata counts, as described by
+// InventoryCenter.Facets, and one carrying an l8inventory.L8InventoryOpenAPI
+// returns the OpenAPI document of the inventory.
 //
 // Returns the matching elements or an error container if the query fails.
 func (this *InventoryService) Get(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
 	vnic.Resources().Logger().Debug("Get Executed...")
+	if _, ok := pb.Element().(*l8inventory.L8InventoryMetrics); ok {
+		return this.metrics()
+	}
+	if facets, ok := pb.Element().(*l8inventory.L8InventoryFacets); ok {
+		return this.facets(facets)
+	}
+	if _, ok := pb.Element().(*l8inventory.L8InventoryOpenAPI); ok {
+		return this.openAPI()
+	}
 
 	result, ok := this.isSingleElement(pb, vnic)
 	if ok {
 		return result
 	}
+	opts, pb, err := this.inventoryCenter.queryOptions(pb)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	if elems, stats, ok, err := this.inventoryCenter.searchRequest(pb, opts); ok {
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		return object.NewQueryResult(elems, stats)
+	}
 
 	query, err := pb.Query(vnic.Resources())
 	if err != nil {
 		return object.NewError(err.Error())
 	}
-	elems, stats := this.inventoryCenter.Get(query)
+	elems, stats := this.inventoryCenter.GetWith(query, opts)
 	vnic.Resources().Logger().Debug("Get Completed with ", len(elems), " elements for query:")
 	return object.NewQueryResult(elems, stats)
 }
 
-// GetCopy handles requests for a copy of inventory items. Currently not implemented.
-// Returns nil.
+// GetCopy handles requests for a copy of inventory items. It serves the request
+// from the local replica, without going through the leader, so read-heavy clients
+// can spread their queries across nodes. Like Get it supports single element
+// lookups and queries, but returns deep copies of the cached elements. The
+// metadata of every response carries the replica's freshness: the time in
+// milliseconds since it last applied a write, under LastAppliedAgeKey.
+//
+// Returns the copied elements or an error container if the query fails.
 func (this *InventoryService) GetCopy(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
-	return nil
+	result, ok := this.isSingleElement(pb, vnic)
+	if ok {
+		if result.Error() != nil {
+			return result
+		}
+		return object.NewQueryResult(this.inventoryCenter.copies(result.Elements(), nil))
+	}
+	opts, pb, err := this.inventoryCenter.queryOptions(pb)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	if elems, stats, ok, err := this.inventoryCenter.searchRequest(pb, opts); ok {
+		if err != nil {
+			return object.NewError(err.Error())
+		}
+		return object.NewQueryResult(this.inventoryCenter.copies(elems, stats))
+	}
+
+	query, err := pb.Query(vnic.Resources())
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	elems, stats, _ := this.inventoryCenter.GetCopy(query, opts)
+	return object.NewQueryResult(elems, stats)
 }
 
 // Failed handles failure notifications for operations that could not be completed.
@@ -295,13 +443,53 @@ func (this *InventoryService) KeyOf(elements ifs.IElements, resources ifs.IResou
 }
 
 // WebService returns the web service configuration for REST API endpoints.
-// It registers a GET endpoint that accepts L8Query and returns the service item list.
+// It registers:
+//   - GET with an L8Query, returning the matching elements as the service item list
+//   - GET with a service item, returning the elements with the same primary key as
+//     the service item list: the element itself if every key field is set, or all
+//     elements matching the key fields that are set
+//   - GET with an l8inventory.L8InventoryMetrics, returning the metrics of the
+//     node serving the request in the Prometheus text format
+//   - GET with an l8inventory.L8InventoryFacets, returning the metadata counts
+//   - GET with an l8inventory.L8InventoryOpenAPI, returning the OpenAPI document
+//     of the inventory
+//   - POST, PUT, PATCH and DELETE with a service item list, applying the action to
+//     every item; a single element is sent as a list of one
+//
+// Each action is registered once per body type, so the web layer can tell the
+// GET requests apart by their body.
 func (this *InventoryService) WebService() ifs.IWebService {
 	ws := web.New(this.sla.ServiceName(), this.sla.ServiceArea(), 0)
-	ws.AddEndpoint(&l8api.L8Query{}, ifs.GET, this.sla.ServiceItemList().(proto.Message))
+	for _, ep := range this.endpoints() {
+		ws.AddEndpoint(ep.body, ep.action, ep.response)
+	}
 	return ws
 }
 
+// endpoint is a REST endpoint of the inventory web service.
+type endpoint struct {
+	action   ifs.Action
+	body     proto.Message
+	response proto.Message
+}
+
+// endpoints returns the REST endpoints registered by WebService.
+func (this *InventoryService) endpoints() []*endpoint {
+	item := this.sla.ServiceItem().(proto.Message)
+	list := this.sla.ServiceItemList().(proto.Message)
+	result := []*endpoint{
+		{action: ifs.GET, body: &l8api.L8Query{}, response: list},
+		{action: ifs.GET, body: item, response: list},
+		{action: ifs.GET, body: &l8inventory.L8InventoryMetrics{}, response: &l8inventory.L8InventoryMetrics{}},
+		{action: ifs.GET, body: &l8inventory.L8InventoryFacets{}, response: &l8inventory.L8InventoryFacets{}},
+		{action: ifs.GET, body: &l8inventory.L8InventoryOpenAPI{}, response: &l8inventory.L8InventoryOpenAPI{}},
+	}
+	for _, action := range []ifs.Action{ifs.POST, ifs.PUT, ifs.PATCH, ifs.DELETE} {
+		result = append(result, &endpoint{action: action, body: list, response: list})
+	}
+	return result
+}
+
 // ItemListType is a utility function that creates a new instance of the list type
 // for a given element type. It looks up the list type by appending "List" to the
 // element type name.
@@ -324,33 +512,77 @@ func ItemListType(r ifs.IRegistry, any interface{}) proto.Message {
 }
 
 // isSingleElement checks if the request is for a single element lookup (as opposed
-// to a query). If the element type matches the service item type, it performs a
-// primary key lookup and returns the result.
+// to a query). If the element type matches the service item type, it looks the
+// element up by its primary key:
+//   - If every primary key field is set, the element is fetched by its full
+//     composite key.
+//   - Otherwise a query is built on the primary key fields that are set, e.g. all
+//     pods of a ClusterName, with each value formatted and escaped for its type.
+//
+// Only string fields can tell an unset key from a set one, as 0 and false are
+// valid numeric and boolean keys. A string key field is set if it is not empty;
+// numeric and boolean key fields count as set, zero values included, unless a
+// string key field is unset, in which case only their non-zero values restrict
+// the query.
 //
 // Returns (result, true) if single element lookup was performed, (nil, false) otherwise.
+// A lookup that cannot be performed yields an error container rather than a panic.
 func (this *InventoryService) isSingleElement(pb ifs.IElements, vnic ifs.IVNic) (ifs.IElements, bool) {
 	ins, ok := pb.Element().(proto.Message)
-	if ok {
-		aside := reflect.ValueOf(ins).Elem().Type().Name()
-		bside := reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name()
-		if aside == bside {
-			rnode, ok1 := vnic.Resources().Introspector().NodeByTypeName(bside)
-			if ok1 {
-				fields, _ := vnic.Resources().Introspector().Decorators().Fields(rnode, l8reflect.L8DecoratorType_Primary)
-				v := reflect.ValueOf(ins).Elem().FieldByName(fields[0])
-				gsql := "select * from " + bside + " where " + fields[0] + "=" + v.String()
-				q1, err := object.NewQuery(gsql, vnic.Resources())
-				if err != nil {
-					panic(gsql + " " + err.Error())
-				}
-				q2, err := q1.Query(vnic.Resources())
-				if err != nil {
-					panic(gsql + " " + err.Error())
-				}
-				result, _ := this.inventoryCenter.Get(q2)
-				return object.New(nil, result), true
-			}
+	if !ok {
+		return nil, false
+	}
+	aside := reflect.ValueOf(ins).Elem().Type().Name()
+	bside := reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name()
+	if aside != bside {
+		return nil, false
+	}
+	fields := this.inventoryCenter.primaryKeyAttributes
+	if len(fields) == 0 {
+		return object.NewError("no primary key defined for " + bside), true
+	}
+	v := reflect.ValueOf(ins).Elem()
+	full := true
+	for _, field := range fields {
+		f := v.FieldByName(field)
+		if !f.IsValid() {
+			return object.NewError("primary key field " + field + " does not exist in " + bside), true
+		}
+		if f.Kind() == reflect.String && f.Len() == 0 {
+			full = false
+		}
+	}
+	if full {
+		elem := this.inventoryCenter.ElementByElement(ins)
+		if elem == nil {
+			return object.New(nil, []interface{}{}), true
+		}
+		return object.New(nil, []interface{}{elem}), true
+	}
+	conditions := make([]string, 0, len(fields))
+	for _, field := range fields {
+		f := v.FieldByName(field)
+		if f.IsZero() {
+			continue
+		}
+		value, err := gsqlValue(f)
+		if err != nil {
+			return object.NewError("primary key field " + field + ": " + err.Error()), true
 		}
+		conditions = append(conditions, field+"="+value)
+	}
+	if len(conditions) == 0 {
+		return object.NewError("no primary key field is set for a " + bside + " lookup"), true
+	}
+	gsql := "select * from " + bside + " where " + strings.Join(conditions, " and ")
+	q1, err := object.NewQuery(gsql, vnic.Resources())
+	if err != nil {
+		return object.NewError(gsql + " " + err.Error()), true
+	}
+	q2, err := q1.Query(vnic.Resources())
+	if err != nil {
+		return object.NewError(gsql + " " + err.Error()), true
 	}
-	return nil, false
+	result, _ := this.inventoryCenter.Get(q2)
+	return object.New(nil, result), true
 }
@@ -0,0 +1,344 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/saichler/l8pollaris/go/pollaris/targets"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// PersistSinkName is the name of the sink created from the SLA links id, which
+// forwards to the persistence service resolved via targets.Links.Persist.
+const PersistSinkName = "persist"
+
+// Sink is a destination for inventory changes. An InventoryService batches and
+// coalesces the changes of every sink separately and hands each flushed batch to
+// Send. Send may be called concurrently when the sink's MaxInFlight is above 1.
+type Sink interface {
+	// Send delivers a batch of elements forwarded with the given action
+	Send(action ifs.Action, elements []interface{}) error
+	// Close releases the sink's resources once no more batches will be sent
+	Close() error
+}
+
+// FailureSink is implemented by sinks that want to know about batches that could
+// not be delivered. Failed is called once a batch failed its last retry; the batch
+// is not forwarded again.
+type FailureSink interface {
+	// Failed receives a batch that could not be sent and the last send error
+	Failed(action ifs.Action, elements []interface{}, err error)
+}
+
+// PermanentError wraps a send error that retrying would not fix, e.g. a batch the
+// receiver rejected as invalid. The forwarder reports such a batch as failed
+// without spending its retries on it.
+type PermanentError struct {
+	Err error
+}
+
+// Error returns the wrapped error's message.
+func (this *PermanentError) Error() string {
+	return this.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (this *PermanentError) Unwrap() error {
+	return this.Err
+}
+
+// TransactionSink is implemented by sinks that can deliver the operations of a
+// transaction as one unit. Sinks that do not implement it receive a transaction as
+// consecutive Send calls, one per run of operations with the same action, with no
+// other batch sent in between.
+type TransactionSink interface {
+	// SendTransaction delivers the operations of a transaction, in order
+	SendTransaction(tx *Transaction) error
+}
+
+// SinkConfig describes a sink an inventory forwards its changes to, together with
+// its batching settings and the changes it accepts. SinkConfigs can be passed as
+// SLA arguments or added at runtime through InventoryService.AddSink.
+//
+// Example, persisting everything while sending only non-UP devices to analytics:
+//
+//	sla.SetArgs(linksId, &inventory.SinkConfig{
+//	    Name:        "analytics",
+//	    ServiceName: "Analytics",
+//	    Actions:     []ifs.Action{ifs.PATCH, ifs.PUT},
+//	    Filter:      "status!=1",
+//	})
+type SinkConfig struct {
+	// Name identifies the sink within the inventory and must be unique
+	Name string
+	// Sink is the destination. If nil, the sink is the leader of ServiceName/ServiceArea.
+	Sink Sink
+	// ServiceName is the Layer 8 service to forward to when Sink is nil
+	ServiceName string
+	// ServiceArea is the area of ServiceName
+	ServiceArea byte
+	// Forward holds the sink's batching settings, nil for the defaults
+	Forward *ForwardConfig
+	// Actions lists the actions forwarded to the sink, empty for all actions
+	Actions []ifs.Action
+	// Filter is a GSQL where-clause predicate elements must match to be forwarded,
+	// e.g. "status!=1". Deleted elements are matched by their last cached state.
+	Filter string
+}
+
+// serviceSink sends batches to the leader of a Layer 8 service.
+type serviceSink struct {
+	nic         ifs.IVNic
+	serviceName string
+	serviceArea byte
+}
+
+// NewServiceSink creates a sink that sends each batch as a request to the leader
+// of the given Layer 8 service.
+func NewServiceSink(nic ifs.IVNic, serviceName string, serviceArea byte) Sink {
+	return &serviceSink{nic: nic, serviceName: serviceName, serviceArea: serviceArea}
+}
+
+// Send sends the batch to the service leader and returns the error it replied with.
+func (this *serviceSink) Send(action ifs.Action, elements []interface{}) error {
+	return leaderRequest(this.nic, this.serviceName, this.serviceArea, action, elements)
+}
+
+// Close is a no-op for service sinks.
+func (this *serviceSink) Close() error {
+	return nil
+}
+
+// linkSink sends batches to the persistence service of a pollaris links id. The
+// target is resolved on every send so changes to the links take effect immediately.
+type linkSink struct {
+	nic     ifs.IVNic
+	linksId string
+}
+
+// Send sends the batch to the leader of the linked persistence service.
+func (this *linkSink) Send(action ifs.Action, elements []interface{}) error {
+	pServiceName, pServiceArea := targets.Links.Persist(this.linksId)
+	return leaderRequest(this.nic, pServiceName, pServiceArea, action, elements)
+}
+
+// Close is a no-op for link sinks.
+func (this *linkSink) Close() error {
+	return nil
+}
+
+// leaderRequest sends elements to the leader of a service and returns the error
+// carried by the response, if any.
+func leaderRequest(nic ifs.IVNic, serviceName string, serviceArea byte, action ifs.Action, elements []interface{}) error {
+	resp := nic.LeaderRequest(serviceName, serviceArea, action, elements, 30)
+	if resp != nil && resp.Error() != nil {
+		return resp.Error()
+	}
+	return nil
+}
+
+// sinkRoute is an active sink with its forwarder and parsed filter.
+type sinkRoute struct {
+	cfg     *SinkConfig
+	fwd     *forwarder
+	actions map[ifs.Action]bool
+	filter  ifs.IQuery
+}
+
+// accepts reports whether the route forwards changes of the given action.
+func (this *sinkRoute) accepts(action ifs.Action) bool {
+	return len(this.actions) == 0 || this.actions[action]
+}
+
+// matches reports whether the changed element satisfies the route's filter.
+func (this *sinkRoute) matches(change *Change) bool {
+	if this.filter == nil {
+		return true
+	}
+	elem := change.New
+	if elem == nil {
+		elem = change.Old
+	}
+	if elem == nil {
+		elem = change.Element
+	}
+	return this.filter.Match(elem)
+}
+
+// add queues the accepted changes on the route's forwarder. Patches are reduced to
+// their changed fields; a patch that cleared a field, which a PATCH cannot express,
+// is forwarded as a PUT of the full new element. If tx is set, the changes are
+// those of a transaction and are forwarded together as one unit.
+func (this *sinkRoute) add(changes []*Change, center *InventoryCenter, tx bool) {
+	ops := make([]*forwardOp, 0, len(changes))
+	for _, change := range changes {
+		if change.NoOp() || !this.accepts(change.Action) || !this.matches(change) {
+			continue
+		}
+		if change.Action != ifs.PATCH || change.Old == nil {
+			ops = append(ops, &forwardOp{action: change.Action, element: change.Element})
+			continue
+		}
+		if delta, ok := center.delta(change); ok {
+			ops = append(ops, &forwardOp{action: ifs.PATCH, element: delta})
+			continue
+		}
+		if full := center.current(change.Element); full != nil {
+			ops = append(ops, &forwardOp{action: ifs.PUT, element: full})
+		}
+	}
+	if len(ops) > 0 {
+		this.fwd.add(ops, tx)
+	}
+}
+
+// AddSink starts forwarding the inventory's changes to a new sink. Returns an error
+// if the name is empty or taken, no destination is set, or the filter does not parse.
+func (this *InventoryService) AddSink(cfg *SinkConfig) error {
+	if cfg == nil || cfg.Name == "" {
+		return errors.New("sink name is required")
+	}
+	sink := cfg.Sink
+	if sink == nil {
+		if cfg.ServiceName == "" {
+			return errors.New("sink " + cfg.Name + " has no sink or service name")
+		}
+		sink = NewServiceSink(this.nic, cfg.ServiceName, cfg.ServiceArea)
+	}
+	copied := *cfg
+	copied.Sink = sink
+	route := &sinkRoute{cfg: &copied, actions: make(map[ifs.Action]bool)}
+	for _, action := range cfg.Actions {
+		route.actions[action] = true
+	}
+	if cfg.Filter != "" {
+		filter, err := this.sinkFilter(cfg.Filter)
+		if err != nil {
+			return errors.New("sink " + cfg.Name + " filter: " + err.Error())
+		}
+		route.filter = filter
+	}
+	this.sinksMtx.Lock()
+	defer this.sinksMtx.Unlock()
+	for _, existing := range this.sinks {
+		if existing.cfg.Name == cfg.Name {
+			return errors.New("sink " + cfg.Name + " already exists")
+		}
+	}
+	route.fwd = newForwarder(cfg.Forward, sink, this.inventoryCenter.keyOf, this.nic.Resources())
+	this.sinks = append(this.sinks, route)
+	return nil
+}
+
+// RemoveSink stops forwarding to the named sink, flushing its pending changes
+// before closing it. Returns false if no such sink exists.
+func (this *InventoryService) RemoveSink(name string) bool {
+	this.sinksMtx.Lock()
+	defer this.sinksMtx.Unlock()
+	for i, route := range this.sinks {
+		if route.cfg.Name == name {
+			this.sinks = append(this.sinks[:i], this.sinks[i+1:]...)
+			go route.close(this.nic.Resources())
+			return true
+		}
+	}
+	return false
+}
+
+// Sinks returns the names of the active sinks in the order they were added.
+func (this *InventoryService) Sinks() []string {
+	this.sinksMtx.RLock()
+	defer this.sinksMtx.RUnlock()
+	names := make([]string, len(this.sinks))
+	for i, route := range this.sinks {
+		names[i] = route.cfg.Name
+	}
+	return names
+}
+
+// SetSinkForwardConfig replaces the batching settings of the named sink at runtime.
+// Returns false if no such sink exists.
+func (this *InventoryService) SetSinkForwardConfig(name string, cfg *ForwardConfig) bool {
+	route := this.sink(name)
+	if route == nil {
+		return false
+	}
+	route.fwd.setConfig(cfg)
+	return true
+}
+
+// SinkForwardConfig returns the batching settings in effect for the named sink,
+// or nil if no such sink exists.
+func (this *InventoryService) SinkForwardConfig(name string) *ForwardConfig {
+	route := this.sink(name)
+	if route == nil {
+		return nil
+	}
+	return route.fwd.config()
+}
+
+// sink returns the route of the named sink, or nil.
+func (this *InventoryService) sink(name string) *sinkRoute {
+	this.sinksMtx.RLock()
+	defer this.sinksMtx.RUnlock()
+	for _, route := range this.sinks {
+		if route.cfg.Name == name {
+			return route
+		}
+	}
+	return nil
+}
+
+// fanOut hands the changes to every active sink, as one unit per sink if tx is set.
+func (this *InventoryService) fanOut(changes []*Change, tx bool) {
+	this.sinksMtx.RLock()
+	defer this.sinksMtx.RUnlock()
+	for _, route := range this.sinks {
+		route.add(changes, this.inventoryCenter, tx)
+	}
+}
+
+// closeSinks flushes and closes every active sink.
+func (this *InventoryService) closeSinks() {
+	this.sinksMtx.Lock()
+	routes := this.sinks
+	this.sinks = nil
+	this.sinksMtx.Unlock()
+	for _, route := range routes {
+		route.close(this.nic.Resources())
+	}
+}
+
+// close flushes the route's pending changes, waits for them to be sent and closes
+// its sink.
+func (this *sinkRoute) close(resources ifs.IResources) {
+	this.fwd.shutdown()
+	this.fwd.wait()
+	if err := this.cfg.Sink.Close(); err != nil {
+		resources.Logger().Error("Failed to close sink ", this.cfg.Name, ": ", err.Error())
+	}
+}
+
+// sinkFilter parses a where-clause predicate against the service item type.
+func (this *InventoryService) sinkFilter(predicate string) (ifs.IQuery, error) {
+	typeName := reflect.ValueOf(this.sla.ServiceItem()).Elem().Type().Name()
+	elems, err := object.NewQuery("select * from "+typeName+" where "+predicate, this.nic.Resources())
+	if err != nil {
+		return nil, err
+	}
+	return elems.Query(this.nic.Resources())
+}
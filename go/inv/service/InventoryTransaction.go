@@ -0,0 +1,263 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// TxOp is a single operation of an inventory transaction.
+type TxOp struct {
+	// Action is one of POST, PUT, PATCH or DELETE
+	Action ifs.Action
+	// Element is the element the action is applied to
+	Element interface{}
+}
+
+// Transaction is an ordered set of mixed operations, possibly across several keys,
+// that an inventory applies all-or-nothing. Consumers see the operations as one
+// forward per sink and one batch of notifications, never an intermediate state
+// such as a pod removed from its old node but not yet added to the new one.
+//
+// On the wire a transaction is an l8inventory.L8InventoryTransaction sent to the
+// inventory in a POST request. The receiving node applies it and replicates it
+// whole to the other instances, so they apply it as a single request too.
+//
+// Example:
+//
+//	tx := inventory.NewTransaction().Delete(oldPod).Post(newPod).Patch(node)
+//	pb, err := tx.Proto()
+//	resp := vnic.ProximityRequest(serviceName, serviceArea, ifs.POST, pb, 30)
+type Transaction struct {
+	// Ops are applied in order
+	Ops []*TxOp
+}
+
+// NewTransaction creates an empty transaction.
+func NewTransaction() *Transaction {
+	return &Transaction{}
+}
+
+// Post adds a POST of the element to the transaction.
+func (this *Transaction) Post(element interface{}) *Transaction {
+	return this.add(ifs.POST, element)
+}
+
+// Put adds a PUT of the element to the transaction.
+func (this *Transaction) Put(element interface{}) *Transaction {
+	return this.add(ifs.PUT, element)
+}
+
+// Patch adds a PATCH of the element to the transaction.
+func (this *Transaction) Patch(element interface{}) *Transaction {
+	return this.add(ifs.PATCH, element)
+}
+
+// Delete adds a DELETE of the element to the transaction.
+func (this *Transaction) Delete(element interface{}) *Transaction {
+	return this.add(ifs.DELETE, element)
+}
+
+// add appends an operation and returns the transaction for chaining.
+func (this *Transaction) add(action ifs.Action, element interface{}) *Transaction {
+	this.Ops = append(this.Ops, &TxOp{Action: action, Element: element})
+	return this
+}
+
+// Proto returns the wire form of the transaction, with every element packed in an
+// Any. Returns an error if an element is not a proto message.
+func (this *Transaction) Proto() (*l8inventory.L8InventoryTransaction, error) {
+	pb := &l8inventory.L8InventoryTransaction{Ops: make([]*l8inventory.L8InventoryOp, 0, len(this.Ops))}
+	for i, op := range this.Ops {
+		msg, ok := op.Element.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("transaction operation %d: element of type %T is not a proto message", i, op.Element)
+		}
+		element, err := anypb.New(msg)
+		if err != nil {
+			return nil, fmt.Errorf("transaction operation %d: %s", i, err.Error())
+		}
+		pb.Ops = append(pb.Ops, &l8inventory.L8InventoryOp{Action: int32(op.Action), Element: element})
+	}
+	return pb, nil
+}
+
+// TransactionOf returns the transaction carried by its wire form. The element
+// types must be registered with the global proto registry, as generated types are.
+func TransactionOf(pb *l8inventory.L8InventoryTransaction) (*Transaction, error) {
+	tx := NewTransaction()
+	for i, op := range pb.GetOps() {
+		if op.GetElement() == nil {
+			return nil, fmt.Errorf("transaction operation %d: missing element", i)
+		}
+		element, err := op.Element.UnmarshalNew()
+		if err != nil {
+			return nil, fmt.Errorf("transaction operation %d: %s", i, err.Error())
+		}
+		tx.add(ifs.Action(op.Action), element)
+	}
+	return tx, nil
+}
+
+// Apply applies every operation of the transaction to the cache while holding the
+// write lock, so no other write interleaves with it. All operations are validated
+// first. A PATCH or DELETE of an element that does not exist at that point of the
+// transaction fails it, as does a write the cache fails. On failure the operations
+// already applied are rolled back and the error is returned with no changes; the
+// write statistics and last-seen times are only updated once all operations apply.
+//
+// The notification flag is passed to the cache as for any other write: if it is
+// false, the cache replicates every operation to the other nodes on its own. The
+// service applies transactions with it set and replicates them whole instead.
+func (this *InventoryCenter) Apply(tx *Transaction, notification bool) (changes []*Change, err error) {
+	if tx == nil || len(tx.Ops) == 0 {
+		return nil, errors.New("transaction is empty")
+	}
+	for i, op := range tx.Ops {
+		if err := this.validate(op); err != nil {
+			return nil, fmt.Errorf("transaction operation %d: %s", i, err.Error())
+		}
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	changes = make([]*Change, 0, len(tx.Ops))
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("transaction operation %d failed: %v", len(changes), r)
+			this.rollback(changes, notification)
+			changes = nil
+		}
+	}()
+	for i, op := range tx.Ops {
+		if (op.Action == ifs.PATCH || op.Action == ifs.DELETE) && this.ElementByElement(op.Element) == nil {
+			this.rollback(changes, notification)
+			return nil, fmt.Errorf("transaction operation %d: %s of a missing element", i, actionName(op.Action))
+		}
+		change, err := this.applyLocked(op.Action, op.Element, notification, true)
+		changes = append(changes, change)
+		if err != nil {
+			this.rollback(changes, notification)
+			return nil, fmt.Errorf("transaction operation %d failed: %s", i, err.Error())
+		}
+	}
+	this.recordWrites(changes...)
+	return changes, nil
+}
+
+// validate checks that an operation has a supported action and an element of the
+// inventory type with a primary key. String key fields must be set; numeric and
+// boolean key fields may hold their zero value.
+func (this *InventoryCenter) validate(op *TxOp) error {
+	if op == nil || op.Element == nil {
+		return errors.New("missing element")
+	}
+	switch op.Action {
+	case ifs.POST, ifs.PUT, ifs.PATCH, ifs.DELETE:
+	default:
+		return fmt.Errorf("unsupported action %d", op.Action)
+	}
+	v := reflect.ValueOf(op.Element)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Type() != this.elementType {
+		return fmt.Errorf("element of type %T is not a %s", op.Element, this.elementType.Name())
+	}
+	for _, attr := range this.primaryKeyAttributes {
+		if f := v.Elem().FieldByName(attr); f.Kind() == reflect.String && f.Len() == 0 {
+			return errors.New("primary key field " + attr + " is not set")
+		}
+	}
+	return nil
+}
+
+// rollback restores the cache to its state before the given changes, undoing them
+// in reverse order. Failed writes are undone too, in case the cache applied them
+// partially. Must be called with the write lock held.
+func (this *InventoryCenter) rollback(changes []*Change, notification bool) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		existing := this.ElementByElement(change.Element)
+		this.countChange(existing != nil, existing, nil)
+		this.countPlaceholder(this.isPlaceholder(existing), nil)
+		var err error
+		if change.Old == nil {
+			if existing != nil {
+				_, err = this.elements.Delete(change.Element, notification)
+			}
+		} else {
+			_, err = this.elements.Put(change.Old, notification)
+		}
+		if err != nil {
+			this.resources.Logger().Error("Failed to roll back ", change.Key, " of ", this.serviceName, ": ", err.Error())
+		}
+		restored := this.ElementByElement(change.Element)
+		this.countChange(false, nil, restored)
+		this.countPlaceholder(false, restored)
+		this.indexElement(change.Key, restored)
+		if change.unrecord != nil {
+			change.unrecord()
+		}
+	}
+}
+
+// Transaction applies a transaction to the inventory all-or-nothing, as if it had
+// been received in a POST request. On success, the resulting changes are
+// forwarded to every sink as one unit and notified as one batch. Returns an error
+// container if the transaction was rejected.
+func (this *InventoryService) Transaction(tx *Transaction, vnic ifs.IVNic) ifs.IElements {
+	pb, err := tx.Proto()
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	return this.Post(object.New(nil, pb), vnic)
+}
+
+// transaction applies a transaction received in a request. Its operations are
+// written to the local replica only; the transaction is then replicated whole to
+// the other instances of the inventory in a single multicast, so they never see
+// part of it. Changes are published only by the node the request was sent to.
+func (this *InventoryService) transaction(pb *l8inventory.L8InventoryTransaction) ifs.IElements {
+	local := this.nic.Resources().SysConfig().LocalUuid
+	if pb.Source != "" && pb.Source == local {
+		return object.New(nil, this.sla.ServiceItemList())
+	}
+	tx, err := TransactionOf(pb)
+	if err != nil {
+		return object.NewError(err.Error())
+	}
+	changes, err := this.inventoryCenter.Apply(tx, true)
+	if err != nil {
+		if pb.Source != "" {
+			this.nic.Resources().Logger().Error("Failed to apply transaction replicated from ", pb.Source,
+				" to ", this.sla.ServiceName(), ": ", err.Error())
+		}
+		return object.NewError(err.Error())
+	}
+	if pb.Source != "" {
+		return object.New(nil, this.sla.ServiceItemList())
+	}
+	replica := proto.Clone(pb).(*l8inventory.L8InventoryTransaction)
+	replica.Source = local
+	if err := this.nic.Multicast(this.sla.ServiceName(), this.sla.ServiceArea(), ifs.POST, replica); err != nil {
+		this.nic.Resources().Logger().Error("Failed to replicate transaction of ", this.sla.ServiceName(), ": ", err.Error())
+	}
+	this.publishChanges(changes, true)
+	return object.New(nil, this.sla.ServiceItemList())
+}
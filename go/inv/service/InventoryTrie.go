@@ -0,0 +1,174 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"sort"
+	"strings"
+)
+
+// trie is a radix tree of string keys, kept in key order. Adding or removing a
+// key takes time proportional to its length, whatever the number of keys, and
+// the keys starting with a prefix are visited in order without scanning the
+// others. It is not safe for concurrent use.
+type trie struct {
+	root trieNode
+	size int
+}
+
+// trieNode is a node of a trie, reached from its parent by the label.
+type trieNode struct {
+	// label is the part of the key between the parent and this node
+	label string
+	// children are ordered by the first byte of their label, which is unique
+	children []*trieNode
+	// value is the value of the key ending at this node, if set
+	value interface{}
+	set   bool
+}
+
+// child returns the position of the child whose label starts with the byte, or
+// where it would be inserted, and whether it exists.
+func (this *trieNode) child(b byte) (int, bool) {
+	i := sort.Search(len(this.children), func(i int) bool {
+		return this.children[i].label[0] >= b
+	})
+	return i, i < len(this.children) && this.children[i].label[0] == b
+}
+
+// get returns the value of the key.
+func (this *trie) get(key string) (interface{}, bool) {
+	n := &this.root
+	for key != "" {
+		i, found := n.child(key[0])
+		if !found || !strings.HasPrefix(key, n.children[i].label) {
+			return nil, false
+		}
+		n = n.children[i]
+		key = key[len(n.label):]
+	}
+	return n.value, n.set
+}
+
+// put sets the value of the key.
+func (this *trie) put(key string, value interface{}) {
+	n := &this.root
+	for key != "" {
+		i, found := n.child(key[0])
+		if !found {
+			n.children = append(n.children, nil)
+			copy(n.children[i+1:], n.children[i:])
+			n.children[i] = &trieNode{label: key, value: value, set: true}
+			this.size++
+			return
+		}
+		c := n.children[i]
+		common := 0
+		for common < len(c.label) && common < len(key) && c.label[common] == key[common] {
+			common++
+		}
+		if common < len(c.label) {
+			split := &trieNode{label: c.label[:common], children: []*trieNode{c}}
+			c.label = c.label[common:]
+			n.children[i] = split
+			c = split
+		}
+		n = c
+		key = key[common:]
+	}
+	if !n.set {
+		this.size++
+	}
+	n.value = value
+	n.set = true
+}
+
+// delete removes the key. Returns whether it was there.
+func (this *trie) delete(key string) bool {
+	if !this.root.remove(key) {
+		return false
+	}
+	this.size--
+	return true
+}
+
+// remove removes the key below the node, merging the nodes left with a single
+// child and no value into their child.
+func (this *trieNode) remove(key string) bool {
+	if key == "" {
+		if !this.set {
+			return false
+		}
+		this.value = nil
+		this.set = false
+		return true
+	}
+	i, found := this.child(key[0])
+	if !found {
+		return false
+	}
+	c := this.children[i]
+	if !strings.HasPrefix(key, c.label) || !c.remove(key[len(c.label):]) {
+		return false
+	}
+	switch {
+	case c.set:
+	case len(c.children) == 0:
+		this.children = append(this.children[:i], this.children[i+1:]...)
+	case len(c.children) == 1:
+		merged := c.children[0]
+		merged.label = c.label + merged.label
+		this.children[i] = merged
+	}
+	return true
+}
+
+// walkPrefix calls f with every key starting with the prefix and its value, in
+// key order, until f returns false.
+func (this *trie) walkPrefix(prefix string, f func(key string, value interface{}) bool) {
+	n := &this.root
+	path := ""
+	for prefix != "" {
+		i, found := n.child(prefix[0])
+		if !found {
+			return
+		}
+		c := n.children[i]
+		switch {
+		case strings.HasPrefix(prefix, c.label):
+			prefix = prefix[len(c.label):]
+		case strings.HasPrefix(c.label, prefix):
+			prefix = ""
+		default:
+			return
+		}
+		path += c.label
+		n = c
+	}
+	n.walk(path, f)
+}
+
+// walk calls f with the key and value of the node and of every node below it, in
+// key order, until f returns false. Returns false if f did.
+func (this *trieNode) walk(path string, f func(key string, value interface{}) bool) bool {
+	if this.set && !f(path, this.value) {
+		return false
+	}
+	for _, c := range this.children {
+		if !c.walk(path+c.label, f) {
+			return false
+		}
+	}
+	return true
+}
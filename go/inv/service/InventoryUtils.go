@@ -14,9 +14,18 @@
 package inventory
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8api"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // AddEmpty creates and adds a new empty inventory element with only the primary key
@@ -24,9 +33,9 @@ import (
 // is available, or for creating placeholder entries.
 //
 // The method uses reflection to create a new instance of the element type, sets the
-// FIRST primary key field to the provided key value, and posts it to the cache.
-// For composite primary keys, only the first field is populated — callers that need
-// to seed multiple key fields should construct the element themselves.
+// FIRST primary key field to the provided key value, and posts it to the cache as a
+// placeholder. The key is converted to the field's type. For composite primary keys
+// use AddPlaceholder, which sets every key field.
 //
 // Parameters:
 //   - key: The primary key value to set on the new element
@@ -40,7 +49,350 @@ func (this *InventoryCenter) AddEmpty(key string) {
 	}
 	elem := reflect.New(this.elementType)
 	field := elem.Elem().FieldByName(this.primaryKeyAttributes[0])
-	field.Set(reflect.ValueOf(key))
-	element := object.New(nil, elem.Interface())
-	this.Post(element)
+	value, err := convertValue(key, field.Type())
+	if err != nil {
+		this.resources.Logger().Error("AddEmpty: ", err.Error())
+		return
+	}
+	field.Set(value)
+	this.addPlaceholder(elem.Interface())
+}
+
+// AddPlaceholder adds an element with only its primary key set, a placeholder
+// until real data for the key is written. Values are given for every primary key
+// field, in the order of the SLA primary keys, and converted to the field types
+// (e.g. "42" or 42 for an int32 field, 42 for a string field).
+//
+// An element is a placeholder as long as it holds nothing but its primary key, so
+// every replica recognizes it from the replicated element itself. Placeholders are
+// included in query results unless the query excludes them (see QueryOptions), can
+// be recognized with IsPlaceholder, and are replaced as a whole by a Post of the
+// same key.
+//
+// Example:
+//
+//	inventoryCenter.AddPlaceholder("cluster-a", "default/nginx-1234")
+func (this *InventoryCenter) AddPlaceholder(keys ...interface{}) error {
+	if len(keys) != len(this.primaryKeyAttributes) {
+		return fmt.Errorf("expected %d primary key values %v, got %d",
+			len(this.primaryKeyAttributes), this.primaryKeyAttributes, len(keys))
+	}
+	elem := reflect.New(this.elementType)
+	for i, attr := range this.primaryKeyAttributes {
+		field := elem.Elem().FieldByName(attr)
+		if !field.IsValid() {
+			return errors.New("primary key field " + attr + " does not exist in " + this.elementType.Name())
+		}
+		value, err := convertValue(keys[i], field.Type())
+		if err != nil {
+			return errors.New("primary key field " + attr + ": " + err.Error())
+		}
+		field.Set(value)
+	}
+	return this.addPlaceholder(elem.Interface())
+}
+
+// addPlaceholder posts the element. An existing element with the same key is left
+// untouched. Returns the error of a failed Post.
+func (this *InventoryCenter) addPlaceholder(elem interface{}) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if this.ElementByElement(elem) != nil {
+		return nil
+	}
+	change, err := this.applyLocked(ifs.POST, elem, false, false)
+	this.recordWrites(change)
+	return err
+}
+
+// IsPlaceholder reports whether the element with the same primary key is a
+// placeholder that no real data was written for yet.
+func (this *InventoryCenter) IsPlaceholder(elem interface{}) bool {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.isPlaceholder(this.ElementByElement(elem))
+}
+
+// isPlaceholder reports whether a cached element holds nothing but its primary key.
+func (this *InventoryCenter) isPlaceholder(elem interface{}) bool {
+	pb, ok := elem.(proto.Message)
+	if !ok || !pb.ProtoReflect().IsValid() {
+		return false
+	}
+	placeholder := true
+	pb.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		placeholder = this.keyFields[fd.Number()]
+		return placeholder
+	})
+	return placeholder
+}
+
+// countPlaceholder updates the placeholder count with a write that replaced an
+// element that was a placeholder or not by new, nil if the element does not exist
+// after. Must be called with the write lock held.
+func (this *InventoryCenter) countPlaceholder(was bool, new interface{}) {
+	if was {
+		this.placeholders--
+	}
+	if this.isPlaceholder(new) {
+		this.placeholders++
+	}
+}
+
+// keyFieldNumbers returns the proto field numbers of the primary key fields of the
+// element type, read from their protobuf struct tags.
+func keyFieldNumbers(elementType reflect.Type, attrs []string) map[protoreflect.FieldNumber]bool {
+	numbers := make(map[protoreflect.FieldNumber]bool, len(attrs))
+	for _, attr := range attrs {
+		field, ok := elementType.FieldByName(attr)
+		if !ok {
+			continue
+		}
+		if parts := strings.Split(field.Tag.Get("protobuf"), ","); len(parts) > 1 {
+			if number, err := strconv.Atoi(parts[1]); err == nil {
+				numbers[protoreflect.FieldNumber(number)] = true
+			}
+		}
+	}
+	return numbers
+}
+
+// QueryOptions holds the settings of a query that GSQL does not express. A nil
+// *QueryOptions stands for the defaults.
+type QueryOptions struct {
+	// ExcludePlaceholders leaves placeholders out of the results. They are filtered
+	// before paging, so pages stay full and the Total count excludes them too. In a
+	// GSQL query it is set by the without-placeholders keyword.
+	ExcludePlaceholders bool
+}
+
+// excludePlaceholders reports whether the options exclude placeholders.
+func (this *QueryOptions) excludePlaceholders() bool {
+	return this != nil && this.ExcludePlaceholders
+}
+
+// withoutPlaceholdersKeyword matches the without-placeholders keyword of a GSQL query.
+var withoutPlaceholdersKeyword = regexp.MustCompile(`(?i)\s+without-placeholders\b`)
+
+// splitOptions removes the keywords of the query options from a GSQL query,
+// returning the options and the remaining query.
+func splitOptions(gsql string) (*QueryOptions, string) {
+	opts := &QueryOptions{}
+	if withoutPlaceholdersKeyword.MatchString(gsql) {
+		opts.ExcludePlaceholders = true
+		gsql = withoutPlaceholdersKeyword.ReplaceAllString(gsql, "")
+	}
+	return opts, gsql
+}
+
+// queryOptions returns the options of a query request and the request without
+// their keywords. Requests that are not GSQL queries are returned as is.
+func (this *InventoryCenter) queryOptions(pb ifs.IElements) (*QueryOptions, ifs.IElements, error) {
+	pquery, ok := pb.Element().(*l8api.L8Query)
+	if !ok {
+		return nil, pb, nil
+	}
+	opts, rest := splitOptions(pquery.Text)
+	if rest == pquery.Text {
+		return opts, pb, nil
+	}
+	stripped, err := object.NewQuery(rest, this.resources)
+	if err != nil {
+		return nil, nil, err
+	}
+	return opts, stripped, nil
+}
+
+// withoutPlaceholders filters placeholder elements out of the results if the
+// options exclude them. Must be called with the write lock held.
+func (this *InventoryCenter) withoutPlaceholders(elems []interface{}, opts *QueryOptions) []interface{} {
+	if !opts.excludePlaceholders() || this.placeholders == 0 {
+		return elems
+	}
+	result := make([]interface{}, 0, len(elems))
+	for _, elem := range elems {
+		if !this.isPlaceholder(elem) {
+			result = append(result, elem)
+		}
+	}
+	return result
+}
+
+// itemsOf expands item lists, i.e. messages with a List field of the element type,
+// into their items. Other elements are returned as is.
+func (this *InventoryCenter) itemsOf(elements []interface{}) []interface{} {
+	var result []interface{}
+	for i, element := range elements {
+		list, ok := this.listItems(element)
+		if !ok {
+			if result != nil {
+				result = append(result, element)
+			}
+			continue
+		}
+		if result == nil {
+			result = append(make([]interface{}, 0, len(elements)+list.Len()), elements[:i]...)
+		}
+		for j := 0; j < list.Len(); j++ {
+			result = append(result, list.Index(j).Interface())
+		}
+	}
+	if result == nil {
+		return elements
+	}
+	return result
+}
+
+// listItems returns the List field of an item list element.
+func (this *InventoryCenter) listItems(element interface{}) (reflect.Value, bool) {
+	v := reflect.ValueOf(element)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type() == this.elementType {
+		return reflect.Value{}, false
+	}
+	list := v.Elem().FieldByName("List")
+	if !list.IsValid() || list.Kind() != reflect.Slice || list.Type().Elem() != reflect.PointerTo(this.elementType) {
+		return reflect.Value{}, false
+	}
+	return list, true
+}
+
+// convertValue converts a value to the given scalar type. Strings are parsed into
+// numbers and booleans, and any value is formatted when the target is a string.
+func convertValue(value interface{}, t reflect.Type) (reflect.Value, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return reflect.Value{}, errors.New("nil value")
+	}
+	if v.Type() == t {
+		return v, nil
+	}
+	result := reflect.New(t).Elem()
+	if t.Kind() == reflect.String {
+		result.SetString(fmt.Sprint(value))
+		return result, nil
+	}
+	if v.Kind() == reflect.String {
+		str := v.String()
+		switch t.Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(str)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetBool(b)
+			return result, nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i, err := strconv.ParseInt(str, 10, t.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetInt(i)
+			return result, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			u, err := strconv.ParseUint(str, 10, t.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetUint(u)
+			return result, nil
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(str, t.Bits())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.SetFloat(f)
+			return result, nil
+		}
+	} else if isNumeric(v.Kind()) && isNumeric(t.Kind()) {
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, t.String())
+}
+
+// isNumeric reports whether the kind is an integer or floating point kind.
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// keyEscaper escapes backslashes and colons in primary key values, so values
+// containing "::" cannot make two different composite keys join to the same string.
+var keyEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`)
+
+// keyOf returns the primary key of an element as a string, composed of the values
+// of all primary key fields joined by "::", with backslashes and colons in the
+// values escaped by a backslash. It is used to identify elements in internal
+// indexes and does not depend on the distributed cache key format.
+func (this *InventoryCenter) keyOf(elem interface{}) string {
+	v := reflect.ValueOf(elem)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	values := make([]string, len(this.primaryKeyAttributes))
+	for i, attr := range this.primaryKeyAttributes {
+		f := v.FieldByName(attr)
+		if f.IsValid() {
+			values[i] = keyEscaper.Replace(fmt.Sprint(f.Interface()))
+		}
+	}
+	return strings.Join(values, "::")
+}
+
+// current returns a deep copy of the cached element sharing the primary key of
+// the provided element, or nil if it is not cached.
+func (this *InventoryCenter) current(elem interface{}) interface{} {
+	cached := this.ElementByElement(elem)
+	if cached == nil {
+		return nil
+	}
+	return cloneElement(cached)
+}
+
+// cloneElement returns a deep copy of a proto element, or the element itself if
+// it is not a proto message.
+func cloneElement(elem interface{}) interface{} {
+	if pb, ok := elem.(proto.Message); ok {
+		return proto.Clone(pb)
+	}
+	return elem
+}
+
+// copyElements returns deep copies of the elements.
+func copyElements(elems []interface{}) []interface{} {
+	result := make([]interface{}, len(elems))
+	for i, elem := range elems {
+		result[i] = cloneElement(elem)
+	}
+	return result
+}
+
+// gsqlValue formats a scalar field value as a GSQL literal. Numbers and booleans
+// are written as is; strings are single quoted with backslashes and quotes escaped.
+func gsqlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		escaped := strings.ReplaceAll(v.String(), "\\", "\\\\")
+		escaped = strings.ReplaceAll(escaped, "'", "\\'")
+		return "'" + escaped + "'", nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), nil
+	}
+	return "", errors.New("unsupported type " + v.Type().String())
 }
@@ -0,0 +1,176 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inventory
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// Webhook request headers. When a secret is configured, SignatureHeader carries
+// "sha256=" followed by the hex HMAC-SHA256 of the TimestampHeader value, a ".",
+// and the request body, so receivers can reject forged or replayed requests.
+const (
+	SignatureHeader = "X-L8-Signature"
+	TimestampHeader = "X-L8-Timestamp"
+)
+
+// DefaultWebhookTimeout is the request timeout used when WebhookSinkConfig.Timeout
+// is left at zero.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// WebhookSinkConfig configures an HTTP webhook sink. Batching is controlled by the
+// ForwardConfig of the SinkConfig the webhook is added with.
+type WebhookSinkConfig struct {
+	// URL is the endpoint each batch is POSTed to
+	URL string
+	// Secret is the HMAC-SHA256 key used to sign requests. Empty disables signing.
+	Secret string
+	// Headers are added to every request
+	Headers map[string]string
+	// Timeout bounds a single request
+	Timeout time.Duration
+}
+
+// WebhookPayload is the JSON body POSTed by the webhook sink for every batch.
+type WebhookPayload struct {
+	// Time is the time the batch was sent, in milliseconds
+	Time int64 `json:"time"`
+	// Action is the forwarded action, e.g. "PATCH"
+	Action string `json:"action"`
+	// Elements are the batch elements in protojson format
+	Elements []json.RawMessage `json:"elements"`
+	// Ops are the operations of a transaction, in order, with Action set to
+	// "TRANSACTION" and Elements empty
+	Ops []*WebhookOp `json:"ops,omitempty"`
+}
+
+// WebhookOp is a single operation of a transaction POSTed by the webhook sink.
+type WebhookOp struct {
+	// Action is the operation's action, e.g. "DELETE"
+	Action string `json:"action"`
+	// Element is the element in protojson format
+	Element json.RawMessage `json:"element"`
+}
+
+// webhookSink POSTs batches of forwarded elements to an HTTP endpoint.
+type webhookSink struct {
+	cfg    *WebhookSinkConfig
+	client *http.Client
+}
+
+// NewWebhookSink creates a sink that POSTs every batch as a WebhookPayload to the
+// configured URL. The sink makes a single request per batch; failed batches are
+// retried by the forwarder as configured by ForwardConfig.Retries. Transport errors
+// and 429 or 5xx responses are retried, other 4xx responses are returned as a
+// PermanentError and fail the batch immediately.
+//
+// Example:
+//
+//	sink, err := inventory.NewWebhookSink(&inventory.WebhookSinkConfig{URL: "https://hooks.example.com/inv", Secret: key})
+//	svc.AddSink(&inventory.SinkConfig{Name: "webhook", Sink: sink,
+//	    Forward: &inventory.ForwardConfig{BatchSize: 200, FlushInterval: 2 * time.Second}})
+func NewWebhookSink(cfg *WebhookSinkConfig) (Sink, error) {
+	if cfg == nil || cfg.URL == "" {
+		return nil, errors.New("webhook sink url is required")
+	}
+	copied := *cfg
+	if copied.Timeout <= 0 {
+		copied.Timeout = DefaultWebhookTimeout
+	}
+	return &webhookSink{cfg: &copied, client: &http.Client{Timeout: copied.Timeout}}, nil
+}
+
+// Send POSTs the batch.
+func (this *webhookSink) Send(action ifs.Action, elements []interface{}) error {
+	records, err := encodeElements(elements)
+	if err != nil {
+		return err
+	}
+	return this.send(&WebhookPayload{Time: time.Now().UnixMilli(), Action: actionName(action), Elements: records})
+}
+
+// SendTransaction POSTs the operations of the transaction in a single payload.
+func (this *webhookSink) SendTransaction(tx *Transaction) error {
+	payload := &WebhookPayload{Time: time.Now().UnixMilli(), Action: "TRANSACTION"}
+	for _, op := range tx.Ops {
+		records, err := encodeElements([]interface{}{op.Element})
+		if err != nil {
+			return err
+		}
+		payload.Ops = append(payload.Ops, &WebhookOp{Action: actionName(op.Action), Element: records[0]})
+	}
+	return this.send(payload)
+}
+
+// send POSTs the payload in a single request.
+func (this *webhookSink) send(payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, this.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range this.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	if this.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, "sha256="+SignWebhook(this.cfg.Secret, timestamp, body))
+	}
+	resp, err := this.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = errors.New("webhook " + this.cfg.URL + " responded " + resp.Status)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return err
+	}
+	return &PermanentError{Err: err}
+}
+
+// Close releases the idle connections of the sink's HTTP client.
+func (this *webhookSink) Close() error {
+	this.client.CloseIdleConnections()
+	return nil
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of timestamp, ".", and body with the given
+// secret, as sent in the SignatureHeader. Receivers can use it to verify requests.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,120 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// TestUnchangedWrites verifies that a PATCH or PUT leaving the element unchanged
+// is neither forwarded nor notified, but still refreshes the time it was last seen.
+func TestUnchangedWrites(t *testing.T) {
+	serviceName := "unchanged"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 4)
+	log := vnic.Resources().Logger()
+	events := make(chan *l8notify.L8NotificationSet, 10)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	sla.SetArgs(&inventory.NotifierConfig{Name: "events", Notifier: inventory.NewChannelNotifier(events)})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	sink := utils_inventory.NewMockSink()
+	service.AddSink(&inventory.SinkConfig{Name: "sink", Sink: sink,
+		Forward: &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour}})
+
+	elem := &testtypes.TestProto{MyString: "A", MyInt64: 67, MyInt32: 13}
+	service.Post(object.New(nil, elem), vnic)
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.POST) == 1 && len(events) == 1 }) {
+		log.Fail(t, "Expected the post to be forwarded and notified")
+		return
+	}
+	<-events
+
+	seen := center.LastSeen(elem)
+	time.Sleep(10 * time.Millisecond)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 13}), vnic)
+	if center.LastSeen(elem) <= seen {
+		log.Fail(t, "Expected the unchanged patch to refresh last seen")
+		return
+	}
+	seen = center.LastSeen(elem)
+	time.Sleep(10 * time.Millisecond)
+	service.Put(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt64: 67, MyInt32: 13}), vnic)
+	if center.LastSeen(elem) <= seen {
+		log.Fail(t, "Expected the unchanged put to refresh last seen")
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	if sink.Count(ifs.PATCH) != 0 || sink.Count(ifs.PUT) != 0 || len(events) != 0 {
+		log.Fail(t, "Expected the unchanged writes to be neither forwarded nor notified")
+		return
+	}
+
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 14}), vnic)
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.PATCH) == 1 && len(events) == 1 }) {
+		log.Fail(t, "Expected the changing patch to be forwarded and notified")
+		return
+	}
+}
+
+// TestPatchDelta verifies that a patch is forwarded as its primary key and the
+// fields it changed, leaving out the fields it repeated unchanged.
+func TestPatchDelta(t *testing.T) {
+	serviceName := "delta"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	sink := utils_inventory.NewMockSink()
+	service.AddSink(&inventory.SinkConfig{Name: "sink", Sink: sink,
+		Forward: &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour}})
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello World", MyInt64: 67}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "Hello World", MyInt64: 67, MyInt32: 13}), vnic)
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.PATCH) == 1 }) {
+		log.Fail(t, "Expected the patch to be forwarded")
+		return
+	}
+	batches := sink.Batches()
+	patched, ok := batches[len(batches)-1][0].(*testtypes.TestProto)
+	if !ok || patched.MyString != "Hello World" || patched.MyInt64 != 0 || patched.MyInt32 != 13 {
+		log.Fail(t, "Expected only the key and the changed fields to be forwarded ", patched)
+		return
+	}
+	elem := center.ElementByElement(&testtypes.TestProto{MyString: "Hello World"}).(*testtypes.TestProto)
+	if elem.MyInt64 != 67 || elem.MyInt32 != 13 {
+		log.Fail(t, "Expected the cached element to hold every field")
+		return
+	}
+}
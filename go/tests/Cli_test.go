@@ -0,0 +1,136 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8inventory/go/inv/cli"
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestCliArgs verifies that invalid command lines are rejected with exit code 2
+// before connecting to the vnet, and that the commands exchanging elements fail
+// in a client built without inventory types.
+func TestCliArgs(t *testing.T) {
+	log := topo.VnicByVnetNum(1, 1).Resources().Logger()
+	invalid := [][]string{
+		{},
+		{"unknown"},
+		{"-port", "none", "services"},
+		{"services", "extra"},
+		{"query", "select * from TestProto"},
+		{"query", "-s", "clitest", "-o", "xml", "select * from TestProto"},
+		{"query", "-s", "clitest", "select", "*"},
+		{"get", "-s", "clitest", "myString=C1"},
+		{"get", "-s", "clitest", "-t", "TestProto"},
+		{"post", "-s", "clitest", "-t", "TestProto"},
+		{"delete", "-s", "clitest", "a.json", "b.json"},
+		{"tail", "extra"},
+		{"tail", "-n", " , "},
+	}
+	for _, args := range invalid {
+		var out bytes.Buffer
+		if code := cli.Run(args, &out, &testtypes.TestProto{}); code != 2 || out.Len() != 0 {
+			log.Fail(t, "Expected ", args, " to be rejected with exit code 2, got ", code)
+			return
+		}
+	}
+	var out bytes.Buffer
+	if code := cli.Run([]string{"query", "-s", "clitest", "select * from TestProto"}, &out); code != 1 {
+		log.Fail(t, "Expected a query without inventory types to fail, got ", code)
+		return
+	}
+}
+
+// TestCli verifies the client against an inventory service: applying elements
+// read from JSON Lines and JSON array files, getting an element by a key given
+// as field=value arguments and printing it as JSON Lines, and printing query
+// results as a table.
+func TestCli(t *testing.T) {
+	serviceName := "clitest"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 2)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	run := func(args ...string) (int, string) {
+		var out bytes.Buffer
+		args = append([]string{"-port", "20000", "-alias", "clitest", "-timeout", "10s"}, args...)
+		code := cli.Run(args, &out, &testtypes.TestProto{}, &testtypes.TestProtoList{})
+		return code, out.String()
+	}
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "elements.jsonl")
+	os.WriteFile(lines, []byte("{\"myString\":\"C1\",\"myInt32\":1}\n{\"myString\":\"C2\",\"myInt32\":2}\n"), 0644)
+	array := filepath.Join(dir, "elements.json")
+	os.WriteFile(array, []byte(`[{"myString":"C3","myInt32":3}]`), 0644)
+	broken := filepath.Join(dir, "broken.json")
+	os.WriteFile(broken, []byte(`[{"myString":"C4"},{"noSuchField":1}]`), 0644)
+
+	if code, out := run("post", "-s", serviceName, "-t", "TestProto", lines); code != 0 || out != "POST 2 element(s)\n" {
+		log.Fail(t, "Expected the JSON Lines elements to be posted, got ", code, " ", out)
+		return
+	}
+	if code, out := run("post", "-s", serviceName, "-t", "TestProto", array); code != 0 || out != "POST 1 element(s)\n" {
+		log.Fail(t, "Expected the JSON array element to be posted, got ", code, " ", out)
+		return
+	}
+	if code, _ := run("post", "-s", serviceName, "-t", "TestProto", broken); code != 1 {
+		log.Fail(t, "Expected a file with an invalid element to be rejected, got ", code)
+		return
+	}
+	if !waitFor(5*time.Second, func() bool {
+		return center.ElementByElement(&testtypes.TestProto{MyString: "C2"}) != nil &&
+			center.ElementByElement(&testtypes.TestProto{MyString: "C3"}) != nil
+	}) {
+		log.Fail(t, "Expected the elements applied by the client in the inventory")
+		return
+	}
+	if center.ElementByElement(&testtypes.TestProto{MyString: "C4"}) != nil {
+		log.Fail(t, "Expected no element of the rejected file to be applied")
+		return
+	}
+
+	code, out := run("get", "-s", serviceName, "-t", "TestProto", "-o", "json", "myString=C1")
+	if code != 0 || strings.Count(out, "\n") != 1 || !strings.Contains(out, `"myString":"C1"`) ||
+		!strings.Contains(out, `"myInt32":1`) {
+		log.Fail(t, "Expected the element of the key as a JSON line, got ", code, " ", out)
+		return
+	}
+	if code, _ := run("get", "-s", serviceName, "-t", "TestProto", "myString=Missing"); code != 1 {
+		log.Fail(t, "Expected getting a missing key to fail, got ", code)
+		return
+	}
+
+	code, out = run("query", "-s", serviceName, "select * from testproto where mystring=C3")
+	rows := strings.Split(strings.TrimSpace(out), "\n")
+	if code != 0 || len(rows) != 3 || !strings.Contains(rows[0], "MYSTRING") || !strings.Contains(rows[0], "MYINT32") ||
+		!strings.Contains(rows[1], "C3") || !strings.Contains(rows[1], "3") || rows[2] != "(1 element(s))" {
+		log.Fail(t, "Expected a table of the queried element, got ", code, " ", out)
+		return
+	}
+}
@@ -0,0 +1,79 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestGetCopy verifies that copies of query results and single element lookups
+// share no state with the cache, and that query copies carry the time the replica
+// last applied a write and its age.
+func TestGetCopy(t *testing.T) {
+	serviceName := "copied"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt64: 1}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt64: 2}), vnic)
+
+	elems, err := object.NewQuery("select * from testproto where mystring=*", vnic.Resources())
+	if err != nil {
+		log.Fail(t, "Unable to create query ", err.Error())
+		return
+	}
+	q, err := elems.Query(vnic.Resources())
+	if err != nil {
+		log.Fail(t, "Unable to create query ", err.Error())
+		return
+	}
+	copies, stats, lastApplied := center.GetCopy(q, nil)
+	if len(copies) != 2 || lastApplied == 0 || lastApplied != center.LastApplied() {
+		log.Fail(t, "Expected copies of all elements and a freshness time")
+		return
+	}
+	if age, ok := stats.KeyCount.Counts[inventory.LastAppliedAgeKey]; !ok || age < 0 {
+		log.Fail(t, "Expected the copies metadata to carry the replica age, got ", age)
+		return
+	}
+	for _, c := range copies {
+		c.(*testtypes.TestProto).MyInt64 = -1
+	}
+	if elem := center.ElementByElement(&testtypes.TestProto{MyString: "A"}).(*testtypes.TestProto); elem.MyInt64 != 1 {
+		log.Fail(t, "Expected copies not to share state with the cache")
+		return
+	}
+
+	resp := service.GetCopy(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+	if resp.Error() != nil || len(resp.Elements()) != 1 ||
+		resp.Elements()[0] == center.ElementByElement(&testtypes.TestProto{MyString: "B"}) ||
+		resp.Elements()[0].(*testtypes.TestProto).MyInt64 != 2 {
+		log.Fail(t, "Expected a copy of the single element lookup")
+		return
+	}
+}
@@ -0,0 +1,75 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestExport verifies that a bulk export writes one JSON line or one CSV row under
+// a header per element, leaves placeholders out on request, and reads back through
+// an import without changing the inventory.
+func TestExport(t *testing.T) {
+	serviceName := "exported"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 2}), vnic)
+	if err := center.AddPlaceholder("P"); err != nil {
+		log.Fail(t, "Failed to add placeholder ", err.Error())
+		return
+	}
+
+	var jsonl bytes.Buffer
+	exported, err := center.Export(&jsonl, &inventory.ExportConfig{Format: inventory.JSONLines})
+	if err != nil || exported != 3 || strings.Count(jsonl.String(), "\n") != exported {
+		log.Fail(t, "Expected one JSON line per exported element ", exported, err)
+		return
+	}
+
+	var csvOut bytes.Buffer
+	exported, err = center.Export(&csvOut, &inventory.ExportConfig{Format: inventory.CSV,
+		Columns: []string{"myString", "my_int32"}, ExcludePlaceholders: true})
+	rows := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if err != nil || exported != 2 || len(rows) != 3 || rows[0] != "myString,my_int32" ||
+		!strings.Contains(csvOut.String(), "\nA,1\n") || !strings.Contains(csvOut.String(), "\nB,2\n") {
+		log.Fail(t, "Expected a CSV header and one row per element without the placeholder ", rows, err)
+		return
+	}
+
+	jsonl.Reset()
+	exported, _ = center.Export(&jsonl, &inventory.ExportConfig{Format: inventory.JSONLines, ExcludePlaceholders: true})
+	summary, err := service.Import(&jsonl, &inventory.ImportConfig{Format: inventory.JSONLines, Mode: inventory.ImportPut})
+	if err != nil || summary.Rows != exported || summary.Unchanged != exported || len(summary.Rejected) != 0 {
+		log.Fail(t, "Expected re-importing the export to leave the inventory unchanged ", err)
+		return
+	}
+}
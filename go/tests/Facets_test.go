@@ -0,0 +1,124 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestFacets verifies that typed metadata counts each element once per bucket and
+// sums its values, that the counts appear in the query metadata under escaped
+// keys, that the facets GET evaluates them over a query's matches, and that a
+// delete is subtracted from the counts maintained for the GET without a query.
+func TestFacets(t *testing.T) {
+	serviceName := "faceted"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(2, 4)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	log := vnic.Resources().Logger()
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello World", MyInt32: 13}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello Again", MyInt32: 3}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Goodbye", MyInt32: 1}), vnic)
+	// every greeting falls in the hello bucket twice, to verify it is counted once
+	center.AddTypedMetadata("greeting", func(elem interface{}) []*inventory.Facet {
+		p := elem.(*testtypes.TestProto)
+		if !strings.HasPrefix(p.MyString, "Hello") {
+			return nil
+		}
+		return []*inventory.Facet{
+			{Name: "hello", Value: float64(p.MyInt32), Facets: []*inventory.Facet{{Name: p.MyString}}},
+			{Name: "hello", Value: 1, Facets: []*inventory.Facet{{Name: p.MyString}}}}
+	})
+	facets, err := center.Facets(nil)
+	hello := facets["greeting"].Buckets["hello"]
+	if err != nil || facets["greeting"].Count != 2 || hello == nil || hello.Count != 2 || hello.Sum != 18 ||
+		hello.Buckets["Hello Again"].Count != 1 {
+		log.Fail(t, "Expected each greeting counted once and its values summed ", err)
+		return
+	}
+
+	elems, err := object.NewQuery("select * from testproto where mystring=*", vnic.Resources())
+	if err != nil {
+		log.Fail(t, "Unable to create query ", err.Error())
+		return
+	}
+	q, err := elems.Query(vnic.Resources())
+	if err != nil {
+		log.Fail(t, "Unable to create query ", err.Error())
+		return
+	}
+	_, stats := center.Get(q)
+	if stats == nil || stats.KeyCount.Counts[inventory.FacetKey("greeting", "hello")] != 2 ||
+		stats.KeyCount.Counts[inventory.FacetKey("greeting", "hello", "Hello Again")] != 1 {
+		log.Fail(t, "Expected the facets in the query metadata")
+		return
+	}
+	if key := inventory.FacetKey("a.b", `c\`); key != `a\.b.c\\` {
+		log.Fail(t, "Expected the facet key names escaped, got ", key)
+		return
+	}
+
+	resp := service.Get(object.New(nil, &l8inventory.L8InventoryFacets{
+		Query: "select * from testproto where mystring='Hello Again'"}), vnic)
+	pbFacets, ok := resp.Element().(*l8inventory.L8InventoryFacets)
+	if !ok || resp.Error() != nil {
+		log.Fail(t, "Expected the facets of the query ", resp.Error())
+		return
+	}
+	hello = inventory.FacetsOf(pbFacets)["greeting"].Buckets["hello"]
+	if hello == nil || hello.Count != 1 || hello.Sum != 4 || pbFacets.Metadata[0].Buckets[0].Average != 4 {
+		log.Fail(t, "Expected the exact sum and average of the queried greeting")
+		return
+	}
+
+	count := center.Count()
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "Hello Again"}), vnic)
+	hello = center.Metadata()["greeting"].Buckets["hello"]
+	if hello == nil || hello.Count != 1 || hello.Sum != 14 || hello.Buckets["Hello Again"] != nil ||
+		center.Count() != count-1 {
+		log.Fail(t, "Expected the deleted greeting subtracted once from the maintained counts")
+		return
+	}
+	resp = service.Get(object.New(nil, &l8inventory.L8InventoryFacets{}), vnic)
+	pbFacets, ok = resp.Element().(*l8inventory.L8InventoryFacets)
+	if !ok || resp.Error() != nil {
+		log.Fail(t, "Expected the maintained facets ", resp.Error())
+		return
+	}
+	hello = inventory.FacetsOf(pbFacets)["greeting"].Buckets["hello"]
+	if hello == nil || hello.Count != 1 || hello.Sum != 14 {
+		log.Fail(t, "Expected the maintained counts over the wire without a query")
+		return
+	}
+	_, stats = center.Get(q)
+	if stats == nil || stats.KeyCount.Counts[inventory.FacetKey("greeting", "hello")] != 1 {
+		log.Fail(t, "Expected the delete subtracted from the query metadata")
+		return
+	}
+}
@@ -0,0 +1,231 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/probler/go/prob/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// TestForwarding verifies the batching settings of a sink: flushing by batch
+// bytes, replacing the settings at runtime, the MaxInFlight limit, retrying and
+// reporting failed sends, coalescing pending operations on the same key, and
+// flushing by a short interval.
+func TestForwarding(t *testing.T) {
+	serviceName := "forwarded"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 3)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+
+	post := func(keys ...string) {
+		for _, key := range keys {
+			service.Post(object.New(nil, &testtypes.TestProto{MyString: key}), vnic)
+		}
+	}
+	size := proto.Size(&testtypes.TestProto{MyString: "K0"})
+	sink := utils_inventory.NewMockSink()
+	err := service.AddSink(&inventory.SinkConfig{Name: "sink", Sink: sink,
+		Forward: &inventory.ForwardConfig{BatchSize: 100, BatchBytes: 3 * size, FlushInterval: time.Hour}})
+	if err != nil {
+		vnic.Resources().Logger().Fail(t, "Failed to add sink ", err.Error())
+		return
+	}
+
+	post("K0", "K1", "K2", "K3", "K4", "K5")
+	if !waitFor(5*time.Second, func() bool { return len(sink.Batches()) == 2 }) {
+		vnic.Resources().Logger().Fail(t, "Expected two batches flushed by size, got ", len(sink.Batches()))
+		return
+	}
+	for _, batch := range sink.Batches() {
+		if len(batch) != 3 {
+			vnic.Resources().Logger().Fail(t, "Expected batches of 3 elements, got ", len(batch))
+			return
+		}
+	}
+
+	sink.Reset()
+	post("K6")
+	time.Sleep(100 * time.Millisecond)
+	if len(sink.Batches()) != 0 {
+		vnic.Resources().Logger().Fail(t, "Expected a single element to stay pending")
+		return
+	}
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour})
+	if !waitFor(5*time.Second, func() bool { return len(sink.Batches()) == 1 }) {
+		vnic.Resources().Logger().Fail(t, "Expected the new config to flush the pending element")
+		return
+	}
+	if cfg := service.SinkForwardConfig("sink"); cfg.BatchSize != 1 || cfg.BatchBytes != 0 ||
+		cfg.MaxInFlight != inventory.DefaultForwardMaxInFlight {
+		vnic.Resources().Logger().Fail(t, "Expected the replaced config with defaults for zero fields")
+		return
+	}
+
+	sink.Reset()
+	sink.SetDelay(200 * time.Millisecond)
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 1, MaxInFlight: 2, FlushInterval: time.Hour})
+	for i := 10; i < 16; i++ {
+		post("K" + strconv.Itoa(i))
+	}
+	if !waitFor(5*time.Second, func() bool { return len(sink.Batches()) == 6 }) {
+		vnic.Resources().Logger().Fail(t, "Expected six batches, got ", len(sink.Batches()))
+		return
+	}
+	if sink.MaxInFlight() != 2 {
+		vnic.Resources().Logger().Fail(t, "Expected two sends in flight at most, got ", sink.MaxInFlight())
+		return
+	}
+
+	sink.Reset()
+	sink.SetDelay(0)
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 1, Retries: 1,
+		RetryBackoff: 10 * time.Millisecond, FlushInterval: time.Hour})
+	sink.FailNext(2)
+	post("R0")
+	if !waitFor(5*time.Second, func() bool { return sink.FailedCount(ifs.POST) == 1 }) {
+		vnic.Resources().Logger().Fail(t, "Expected the batch to be reported failed after its retry")
+		return
+	}
+	sink.FailNext(1)
+	post("R1")
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.POST) == 1 }) ||
+		sink.FailedCount(ifs.POST) != 1 {
+		vnic.Resources().Logger().Fail(t, "Expected the retry to deliver the batch")
+		return
+	}
+
+	sink.Reset()
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 100, FlushInterval: time.Hour})
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "C0", MyInt64: 1}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "C0", MyInt32: 2}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "C0", MyInt64: 3}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "K0", MyInt32: 5}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "K0", MyInt64: 7}), vnic)
+	post("D0")
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "D0"}), vnic)
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour})
+	if !waitFor(5*time.Second, func() bool { return len(sink.Batches()) == 2 }) {
+		vnic.Resources().Logger().Fail(t, "Expected a post and a patch batch, got ", len(sink.Batches()))
+		return
+	}
+	batches := sink.Batches()
+	if len(batches[0]) != 1 || len(batches[1]) != 1 || sink.Count(ifs.POST) != 1 || sink.Count(ifs.PATCH) != 1 ||
+		sink.Count(ifs.DELETE) != 0 {
+		vnic.Resources().Logger().Fail(t, "Expected the post and delete of D0 to be dropped")
+		return
+	}
+	posted := batches[0][0].(*testtypes.TestProto)
+	if posted.MyString != "C0" || posted.MyInt64 != 3 || posted.MyInt32 != 2 {
+		vnic.Resources().Logger().Fail(t, "Expected the patches merged into the post ", posted)
+		return
+	}
+	patched := batches[1][0].(*testtypes.TestProto)
+	if patched.MyString != "K0" || patched.MyInt64 != 7 || patched.MyInt32 != 5 {
+		vnic.Resources().Logger().Fail(t, "Expected both patches merged into one ", patched)
+		return
+	}
+
+	sink.Reset()
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 100, FlushInterval: time.Hour})
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "K1"}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "K1", MyInt32: 9}), vnic)
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour})
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.DELETE) == 1 }) {
+		vnic.Resources().Logger().Fail(t, "Expected the delete to be forwarded")
+		return
+	}
+	// the patch is rejected by the cache if the key is missing, otherwise it
+	// follows the delete as a patch rather than a put of a partial element
+	time.Sleep(100 * time.Millisecond)
+	batches = sink.Batches()
+	if sink.Count(ifs.PUT) != 0 || sink.Count(ifs.PATCH) > 1 || batches[0][0].(*testtypes.TestProto).MyString != "K1" {
+		vnic.Resources().Logger().Fail(t, "Expected the patch after the delete not to become a put")
+		return
+	}
+
+	sink.Reset()
+	service.SetSinkForwardConfig("sink", &inventory.ForwardConfig{BatchSize: 100, FlushInterval: time.Millisecond})
+	post("T0")
+	if !waitFor(time.Second, func() bool { return sink.Count(ifs.POST) == 1 }) {
+		vnic.Resources().Logger().Fail(t, "Expected the short flush interval to forward the element")
+		return
+	}
+
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	post("T0")
+	time.Sleep(100 * time.Millisecond)
+	if sink.Count(ifs.POST) != 1 || center.LastSeen(&testtypes.TestProto{MyString: "T0"}) == 0 {
+		vnic.Resources().Logger().Fail(t, "Expected the unchanged post to be suppressed but seen")
+		return
+	}
+	center.SetChangeConfig(&inventory.ChangeConfig{SkipCompare: true, SkipLastSeen: true})
+	post("T0")
+	if !waitFor(time.Second, func() bool { return sink.Count(ifs.POST) == 2 }) ||
+		center.LastSeen(&testtypes.TestProto{MyString: "T0"}) != 0 {
+		vnic.Resources().Logger().Fail(t, "Expected the uncompared post to be forwarded and not seen")
+		return
+	}
+}
+
+// TestPersistForwardConfig verifies that the batching settings of the persist sink
+// of a service activated with a service link can be replaced at runtime, and that
+// a service without a link has none.
+func TestPersistForwardConfig(t *testing.T) {
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 3)
+	log := vnic.Resources().Logger()
+	activate := func(serviceName string, args ...interface{}) *inventory.InventoryService {
+		sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+		sla.SetServiceItem(&testtypes.TestProto{})
+		sla.SetServiceItemList(&testtypes.TestProtoList{})
+		sla.SetPrimaryKeys("MyString")
+		sla.SetArgs(args...)
+		vnic.Resources().Services().Activate(sla, vnic)
+		h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+		return h.(*inventory.InventoryService)
+	}
+
+	linked := activate("linked", common.NetworkDevice_Links_ID)
+	if cfg := linked.ForwardConfig(); cfg == nil || cfg.BatchSize != inventory.DefaultForwardBatchSize {
+		log.Fail(t, "Expected the persist sink to start with the default config")
+		return
+	}
+	linked.SetForwardConfig(&inventory.ForwardConfig{BatchSize: 10, FlushInterval: time.Second})
+	if cfg := linked.ForwardConfig(); cfg == nil || cfg.BatchSize != 10 || cfg.FlushInterval != time.Second {
+		log.Fail(t, "Expected the forward config to be replaced at runtime")
+		return
+	}
+
+	unlinked := activate("unlinked")
+	unlinked.SetForwardConfig(&inventory.ForwardConfig{BatchSize: 10})
+	if unlinked.ForwardConfig() != nil {
+		log.Fail(t, "Expected a service without a link to have no forward config")
+		return
+	}
+}
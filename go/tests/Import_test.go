@@ -0,0 +1,92 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestImport verifies that a bulk import counts unchanged elements apart from
+// applied ones, rejects rows without a key or whose write fails while importing
+// the rest, forwards what it applied, and that ImportReplace deletes the elements
+// that were not imported.
+func TestImport(t *testing.T) {
+	serviceName := "imported"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	sink := utils_inventory.NewMockSink()
+	service.AddSink(&inventory.SinkConfig{Name: "sink", Sink: sink,
+		Forward: &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour}})
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 2}), vnic)
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.POST) == 2 }) {
+		log.Fail(t, "Expected the posts to be forwarded")
+		return
+	}
+
+	summary, err := service.Import(strings.NewReader("{\"myString\":\"A\",\"myInt32\":1}\n{\"myString\":\"B\",\"myInt32\":2}\n"),
+		&inventory.ImportConfig{Format: inventory.JSONLines, Mode: inventory.ImportPut})
+	if err != nil || summary.Rows != 2 || summary.Unchanged != 2 || summary.Applied != 0 || len(summary.Rejected) != 0 {
+		log.Fail(t, "Expected importing the cached elements to leave the inventory unchanged ", err)
+		return
+	}
+
+	summary, err = service.Import(strings.NewReader("myString,myInt32\nC,5\n,6\n"), &inventory.ImportConfig{Format: inventory.CSV})
+	if err != nil || summary.Applied != 1 || len(summary.Rejected) != 1 || summary.Rejected[0].Row != 2 {
+		log.Fail(t, "Expected the CSV row without a key to be rejected ", err)
+		return
+	}
+	imported := center.ElementByElement(&testtypes.TestProto{MyString: "C"})
+	if imported == nil || imported.(*testtypes.TestProto).MyInt32 != 5 {
+		log.Fail(t, "Expected the CSV row to be imported")
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return sink.Count(ifs.POST) == 3 }) || sink.Count(ifs.PUT) != 0 {
+		log.Fail(t, "Expected only the imported element to be forwarded")
+		return
+	}
+
+	summary, err = service.Import(strings.NewReader("myString,myInt32\nMissing,7\nA,8\n"),
+		&inventory.ImportConfig{Format: inventory.CSV, Mode: inventory.ImportPatch})
+	if err != nil || summary.Applied != 1 || len(summary.Rejected) != 1 || summary.Rejected[0].Row != 1 {
+		log.Fail(t, "Expected the patch of a missing element to be rejected ", err)
+		return
+	}
+
+	summary, err = service.Import(strings.NewReader("{\"myString\":\"A\",\"myInt32\":8}\n"),
+		&inventory.ImportConfig{Format: inventory.JSONLines, Mode: inventory.ImportReplace})
+	if err != nil || summary.Unchanged != 1 || summary.Deleted != 2 || center.Count() != 1 ||
+		center.ElementByElement(&testtypes.TestProto{MyString: "B"}) != nil {
+		log.Fail(t, "Expected the replace to delete the elements that were not imported ", err)
+		return
+	}
+}
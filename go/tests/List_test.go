@@ -0,0 +1,60 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestItemList verifies that a service item list, as received from the REST
+// endpoints, applies a POST, PATCH or DELETE to every one of its items.
+func TestItemList(t *testing.T) {
+	serviceName := "listed"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	list := &testtypes.TestProtoList{List: []*testtypes.TestProto{{MyString: "Listed1"}, {MyString: "Listed2"}}}
+	service.Post(object.New(nil, list), vnic)
+	if center.ElementByElement(list.List[0]) == nil || center.ElementByElement(list.List[1]) == nil {
+		log.Fail(t, "Expected every item of a posted list to be added")
+		return
+	}
+	service.Patch(object.New(nil, &testtypes.TestProtoList{List: []*testtypes.TestProto{
+		{MyString: "Listed1", MyInt32: 1}, {MyString: "Listed2", MyInt32: 2}}}), vnic)
+	for i, item := range list.List {
+		if elem, ok := center.ElementByElement(item).(*testtypes.TestProto); !ok || elem.MyInt32 != int32(i+1) {
+			log.Fail(t, "Expected every item of a patched list to be patched")
+			return
+		}
+	}
+	service.Delete(object.New(nil, list), vnic)
+	if center.ElementByElement(list.List[0]) != nil || center.ElementByElement(list.List[1]) != nil {
+		log.Fail(t, "Expected every item of a deleted list to be removed")
+		return
+	}
+}
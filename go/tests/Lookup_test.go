@@ -0,0 +1,122 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestKeyLookup verifies single element lookups by a composite primary key with a
+// numeric field: a full key with a zero value or a space finds its element, and a
+// partial key queries the elements matching the fields that are set.
+func TestKeyLookup(t *testing.T) {
+	serviceName := "keyed"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 1)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString", "MyInt32")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+
+	for _, elem := range []*testtypes.TestProto{
+		{MyString: "A", MyInt32: 0, MyInt64: 10},
+		{MyString: "A", MyInt32: 1, MyInt64: 11},
+		{MyString: "B", MyInt32: 1, MyInt64: 21},
+		{MyString: "Hello World", MyInt32: 2, MyInt64: 31},
+	} {
+		service.Post(object.New(nil, elem), vnic)
+	}
+
+	resp := service.Get(object.New(nil, &testtypes.TestProto{MyString: "A"}), vnic)
+	if resp.Error() != nil || len(resp.Elements()) != 1 || resp.Elements()[0].(*testtypes.TestProto).MyInt64 != 10 {
+		log.Fail(t, "Expected the full key with a zero value to find its element")
+		return
+	}
+	resp = service.Get(object.New(nil, &testtypes.TestProto{MyString: "Hello World", MyInt32: 2}), vnic)
+	if resp.Error() != nil || len(resp.Elements()) != 1 || resp.Elements()[0].(*testtypes.TestProto).MyInt64 != 31 {
+		log.Fail(t, "Expected single element lookup by a key with a space")
+		return
+	}
+	resp = service.Get(object.New(nil, &testtypes.TestProto{MyInt32: 1}), vnic)
+	if resp.Error() != nil || len(resp.Elements()) != 2 {
+		log.Fail(t, "Expected the partial key to query both elements with MyInt32 1, got ", len(resp.Elements()))
+		return
+	}
+	resp = service.Get(object.New(nil, &testtypes.TestProto{}), vnic)
+	if resp.Error() == nil {
+		log.Fail(t, "Expected a lookup with no key field set to return an error")
+		return
+	}
+}
+
+// TestPrefixLookup verifies that the key index matches key prefixes ignoring case,
+// in key order and up to the limit, that it follows writes made after it was
+// built, and that a fuzzy lookup finds a mistyped value once its field is enabled.
+func TestPrefixLookup(t *testing.T) {
+	serviceName := "prefixed"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 1)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	for _, name := range []string{"Hello World", "Hello Again", "Help Desk", "Goodbye"} {
+		service.Post(object.New(nil, &testtypes.TestProto{MyString: name}), vnic)
+	}
+
+	if byPrefix := center.LookupPrefix("hello", 0); len(byPrefix) != 2 {
+		log.Fail(t, "Expected two keys starting with hello ", len(byPrefix))
+		return
+	}
+	byPrefix := center.LookupPrefix("hel", 1)
+	if len(byPrefix) != 1 || byPrefix[0].(*testtypes.TestProto).MyString != "Hello Again" {
+		log.Fail(t, "Expected the limit to return the first key in order")
+		return
+	}
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello There"}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "Hello Again"}), vnic)
+	if byPrefix := center.LookupPrefix("HELLO", 0); len(byPrefix) != 2 {
+		log.Fail(t, "Expected the key index to follow the post and delete ", len(byPrefix))
+		return
+	}
+
+	if _, err := center.LookupFuzzy("myString", "helo wrld", 2, 1); err == nil {
+		log.Fail(t, "Expected a fuzzy lookup of a field that is not enabled to fail")
+		return
+	}
+	if err := center.EnableFuzzy("myString"); err != nil {
+		log.Fail(t, "Expected fuzzy lookup to be enabled ", err)
+		return
+	}
+	matches, err := center.LookupFuzzy("myString", "helo wrld", 2, 1)
+	if err != nil || len(matches) != 1 || matches[0].Value != "Hello World" || matches[0].Distance != 2 {
+		log.Fail(t, "Expected the mistyped key to match Hello World ", err)
+		return
+	}
+}
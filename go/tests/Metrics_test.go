@@ -0,0 +1,84 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestMetrics verifies that the metrics handler exposes the element count, the
+// writes by action and the metadata buckets of an inventory, and that a metrics
+// GET to the service returns the same exposition.
+func TestMetrics(t *testing.T) {
+	serviceName := "measured"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	center.AddMetadata("parity", func(elem interface{}) (bool, string) {
+		if elem.(*testtypes.TestProto).MyInt32%2 == 0 {
+			return true, "even"
+		}
+		return true, "odd"
+	})
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 2}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 4}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 3}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+
+	rec := httptest.NewRecorder()
+	inventory.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE l8inventory_mutations_total counter",
+		`l8inventory_elements{service="measured",area="0"} 1`,
+		`l8inventory_mutations_total{service="measured",area="0",action="post"} 2`,
+		`l8inventory_mutations_total{service="measured",area="0",action="patch"} 1`,
+		`l8inventory_mutations_total{service="measured",area="0",action="delete"} 1`,
+		`l8inventory_metadata_elements{service="measured",area="0",metadata="parity",value="odd"} 1`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			log.Fail(t, "Expected the metrics to contain ", line, " in ", metrics)
+			return
+		}
+	}
+	if strings.Contains(metrics, `metadata="parity",value="even"} 1`) {
+		log.Fail(t, "Expected the patch and delete to leave no even element")
+		return
+	}
+
+	resp := service.Get(object.New(nil, &l8inventory.L8InventoryMetrics{}), vnic)
+	if m, ok := resp.Element().(*l8inventory.L8InventoryMetrics); !ok ||
+		!strings.Contains(m.Text, `l8inventory_mutations_total{service="measured",area="0",action="post"} 2`) {
+		log.Fail(t, "Expected the metrics of the inventory in response to a metrics GET")
+		return
+	}
+}
@@ -0,0 +1,209 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// TestNotifyDebounce verifies that successive changes to a key within the
+// debounce window are notified once with their net effect, and that exceeding
+// the per-second cap sends a single bulk event instead of the excess.
+func TestNotifyDebounce(t *testing.T) {
+	serviceName := "debounced"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 3)
+	events := make(chan *l8notify.L8NotificationSet, 100)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	sla.SetArgs(&inventory.NotifierConfig{Name: "events", Notifier: inventory.NewChannelNotifier(events)},
+		&inventory.NotifyConfig{Fields: true, Debounce: 300 * time.Millisecond, MaxPerSecond: 3})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	log := vnic.Resources().Logger()
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	for i := int32(2); i <= 4; i++ {
+		service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: i}), vnic)
+	}
+	if !waitFor(2*time.Second, func() bool { return len(events) > 0 }) {
+		log.Fail(t, "Expected a debounced notification")
+		return
+	}
+	time.Sleep(500 * time.Millisecond)
+	if len(events) != 1 {
+		log.Fail(t, "Expected 1 debounced notification, got ", len(events))
+		return
+	}
+	if n := <-events; n.ModelKey != "A" || n.Type != l8notify.L8NotificationType_Post {
+		log.Fail(t, "Expected the net effect to be notified as a post of A")
+		return
+	}
+
+	// Wait for the next second so the cap is not shared with the first window
+	next := time.Now().Truncate(time.Second).Add(time.Second)
+	time.Sleep(time.Until(next))
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+	for i := 0; i < 10; i++ {
+		service.Post(object.New(nil, &testtypes.TestProto{MyString: "K" + strconv.Itoa(i)}), vnic)
+	}
+	bulk, single, cancelled := 0, 0, true
+	waitFor(2*time.Second, func() bool {
+		for len(events) > 0 {
+			n := <-events
+			switch n.ModelKey {
+			case inventory.BulkModelKey:
+				bulk++
+			case "B":
+				cancelled = false
+			default:
+				single++
+			}
+		}
+		return bulk == 1 && single == 3
+	})
+	if !cancelled {
+		log.Fail(t, "Expected a post and delete of the same key to cancel out")
+		return
+	}
+	if bulk != 1 || single != 3 {
+		log.Fail(t, "Expected 3 notifications and 1 bulk event, got ", single, " and ", bulk)
+		return
+	}
+}
+
+// TestNotifierActions verifies that a notifier limited to some actions only
+// receives the changes made by them, while a notifier without actions receives
+// every change, and that a removed notifier receives nothing more.
+func TestNotifierActions(t *testing.T) {
+	serviceName := "filtered"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	log := vnic.Resources().Logger()
+
+	all := make(chan *l8notify.L8NotificationSet, 10)
+	deletes := make(chan *l8notify.L8NotificationSet, 10)
+	service.AddNotifier(&inventory.NotifierConfig{Name: "all", Notifier: inventory.NewChannelNotifier(all)})
+	err := service.AddNotifier(&inventory.NotifierConfig{Name: "deletes", Notifier: inventory.NewChannelNotifier(deletes),
+		Actions: []ifs.Action{ifs.DELETE}})
+	if err != nil {
+		log.Fail(t, "Failed to add notifier ", err.Error())
+		return
+	}
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 2}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "A"}), vnic)
+	if !waitFor(2*time.Second, func() bool { return len(all) == 3 && len(deletes) == 1 }) {
+		log.Fail(t, "Expected 3 notifications and 1 delete, got ", len(all), " and ", len(deletes))
+		return
+	}
+	if n := <-deletes; n.ModelKey != "A" || n.Type != l8notify.L8NotificationType_Delete {
+		log.Fail(t, "Expected only the delete to reach the deletes notifier")
+		return
+	}
+
+	if !service.RemoveNotifier("deletes") {
+		log.Fail(t, "Expected the deletes notifier to be removed")
+		return
+	}
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "B"}), vnic)
+	if !waitFor(2*time.Second, func() bool { return len(all) == 5 }) || len(deletes) != 0 {
+		log.Fail(t, "Expected the removed notifier to receive nothing more")
+		return
+	}
+}
+
+// TestWsNotification verifies that the notifications multicast to the WebSocket
+// service carry the escaped composite key of the element and one entry per changed
+// field of a patch, followed by the full element once NotifyConfig.Element is set.
+func TestWsNotification(t *testing.T) {
+	serviceName := "notified"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString", "MyInt64")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	log := vnic.Resources().Logger()
+
+	ci := topo.VnicByVnetNum(1, 4)
+	ws := &utils_inventory.MockWsService{}
+	sla = ifs.NewServiceLevelAgreement(ws, inventory.WsServiceName, inventory.WsServiceArea, false, nil)
+	ci.Resources().Services().Activate(sla, ci)
+	// let the WebSocket service become known to the inventory before the first change
+	time.Sleep(time.Second)
+	last := func(key string, nType l8notify.L8NotificationType) *l8notify.L8NotificationSet {
+		var n *l8notify.L8NotificationSet
+		waitFor(5*time.Second, func() bool {
+			n = ws.Last(key)
+			return n != nil && n.Type == nType
+		})
+		return n
+	}
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "a:b", MyInt64: 7}), vnic)
+	if n := last(`a\:b::7`, l8notify.L8NotificationType_Post); n == nil || n.Type != l8notify.L8NotificationType_Post {
+		log.Fail(t, "Expected a post notification with the escaped composite key")
+		return
+	}
+
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "a:b", MyInt64: 7, MyInt32: 13}), vnic)
+	n := last(`a\:b::7`, l8notify.L8NotificationType_Patch)
+	if n == nil || n.Type != l8notify.L8NotificationType_Patch || len(n.NotificationList) != 1 ||
+		n.NotificationList[0].PropertyId == "" || len(n.NotificationList[0].OldValue) != 0 ||
+		string(n.NotificationList[0].NewValue) != "13" {
+		log.Fail(t, "Expected a patch notification with the changed field")
+		return
+	}
+
+	service.SetNotifyConfig(&inventory.NotifyConfig{Fields: true, Element: true})
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "a:b", MyInt64: 7, MyInt32: 14}), vnic)
+	if !waitFor(5*time.Second, func() bool {
+		n = ws.Last(`a\:b::7`)
+		return n != nil && len(n.NotificationList) == 2
+	}) {
+		log.Fail(t, "Expected a patch notification with the changed field and the element")
+		return
+	}
+	if string(n.NotificationList[0].OldValue) != "13" || string(n.NotificationList[0].NewValue) != "14" ||
+		n.NotificationList[1].PropertyId != "" || len(n.NotificationList[1].NewValue) == 0 {
+		log.Fail(t, "Expected the old and new values of the field followed by the element")
+		return
+	}
+}
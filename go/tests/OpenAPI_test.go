@@ -0,0 +1,84 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/types/l8inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestOpenAPI verifies that the OpenAPI document of an inventory is served by
+// OpenAPIHandler and through the web service, and that it describes the writes
+// as taking and returning the item list and the GET as taking every body type.
+func TestOpenAPI(t *testing.T) {
+	serviceName := "documented"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(1, 3)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	log := vnic.Resources().Logger()
+
+	if service.WebService() == nil {
+		log.Fail(t, "Expected the web service of the inventory")
+		return
+	}
+	rec := httptest.NewRecorder()
+	inventory.OpenAPIHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, inventory.OpenAPIPath+"0/"+serviceName, nil))
+	doc := make(map[string]interface{})
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &doc) != nil || doc["paths"] == nil ||
+		!strings.Contains(rec.Body.String(), `"x-primary-keys"`) {
+		log.Fail(t, "Expected an OpenAPI document of the inventory ", rec.Code)
+		return
+	}
+	resp := service.Get(object.New(nil, &l8inventory.L8InventoryOpenAPI{}), vnic)
+	served, ok := resp.Element().(*l8inventory.L8InventoryOpenAPI)
+	if !ok || resp.Error() != nil || served.Document != rec.Body.String() {
+		log.Fail(t, "Expected the web service to serve the same OpenAPI document ", resp.Error())
+		return
+	}
+
+	operations := doc["paths"].(map[string]interface{})["/0/"+serviceName].(map[string]interface{})
+	listName := (&testtypes.TestProtoList{}).ProtoReflect().Descriptor().FullName()
+	listRef := `"$ref":"#/components/schemas/` + string(listName) + `"`
+	for _, method := range []string{"post", "put", "patch", "delete"} {
+		op, err := json.Marshal(operations[method])
+		if err != nil || !strings.Contains(string(op), `"responses":{"200":{"content":{"application/json":{"schema":{`+listRef+`}}}`) ||
+			!strings.Contains(string(op), `"requestBody":{"content":{"application/json":{"schema":{`+listRef+`}}}`) {
+			log.Fail(t, "Expected the ", method, " endpoint to take and return the item list only ", string(op))
+			return
+		}
+	}
+	get, _ := json.Marshal(operations["get"])
+	for _, schema := range []string{listRef, "l8inventory.L8InventoryMetrics", "l8inventory.L8InventoryFacets",
+		"l8inventory.L8InventoryOpenAPI"} {
+		if !strings.Contains(string(get), schema) {
+			log.Fail(t, "Expected the get endpoint to describe ", schema, " ", string(get))
+			return
+		}
+	}
+}
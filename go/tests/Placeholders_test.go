@@ -0,0 +1,112 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestPlaceholders verifies that a placeholder takes exactly the primary key
+// values, is included in query results unless the query options or the
+// without-placeholders keyword exclude it before paging, and is replaced by a
+// Post of the same key.
+func TestPlaceholders(t *testing.T) {
+	serviceName := "placeheld"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 2}), vnic)
+	if center.AddPlaceholder("Pending", 1) == nil {
+		log.Fail(t, "Expected placeholder with too many keys to be rejected")
+		return
+	}
+	if err := center.AddPlaceholder("Pending"); err != nil {
+		log.Fail(t, "Failed to add placeholder ", err.Error())
+		return
+	}
+	pending := &testtypes.TestProto{MyString: "Pending"}
+	if !center.IsPlaceholder(pending) || center.IsPlaceholder(&testtypes.TestProto{MyString: "A"}) {
+		log.Fail(t, "Expected only Pending to be a placeholder")
+		return
+	}
+
+	query := func(gsql string) ifs.IQuery {
+		elems, err := object.NewQuery(gsql, vnic.Resources())
+		if err != nil {
+			log.Fail(t, "Unable to create query ", err.Error())
+			return nil
+		}
+		q, err := elems.Query(vnic.Resources())
+		if err != nil {
+			log.Fail(t, "Unable to create query ", err.Error())
+			return nil
+		}
+		return q
+	}
+	q := query("select * from testproto where mystring=*")
+	if q == nil {
+		return
+	}
+	if all, _ := center.Get(q); len(all) != 3 {
+		log.Fail(t, "Expected placeholders to be included by default, got ", len(all))
+		return
+	}
+	pq := query("select * from testproto where mystring=* limit 2 page 0")
+	if pq == nil {
+		return
+	}
+	page, stats := center.GetWith(pq, &inventory.QueryOptions{ExcludePlaceholders: true})
+	if len(page) != 2 || stats.KeyCount.Counts["Total"] != 2 {
+		log.Fail(t, "Expected a full page and total without placeholders, got ",
+			len(page), " ", stats.KeyCount.Counts["Total"])
+		return
+	}
+	for _, elem := range page {
+		if center.IsPlaceholder(elem) {
+			log.Fail(t, "Expected placeholders to be excluded")
+			return
+		}
+	}
+	keyword, _ := object.NewQuery("select * from testproto where mystring=* without-placeholders", vnic.Resources())
+	if resp := service.Get(keyword, vnic); resp.Error() != nil || len(resp.Elements()) != 2 {
+		log.Fail(t, "Expected the without-placeholders keyword to exclude placeholders")
+		return
+	}
+
+	pending.MyInt32 = 7
+	service.Post(object.New(nil, pending), vnic)
+	if center.IsPlaceholder(pending) {
+		log.Fail(t, "Expected Post to replace the placeholder")
+		return
+	}
+	if withPending, _ := center.GetWith(q, &inventory.QueryOptions{ExcludePlaceholders: true}); len(withPending) != 3 {
+		log.Fail(t, "Expected the posted element in the results")
+		return
+	}
+}
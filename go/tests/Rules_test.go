@@ -0,0 +1,130 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// TestRules verifies that a rule without a duration raises its alert on the first
+// matching change, a rule with a duration only once the element kept matching,
+// and that both alerts are cleared when the element stops matching. Writes
+// replicated from another instance are evaluated too, and a rule added later
+// raises for the cached elements already matching it.
+func TestRules(t *testing.T) {
+	serviceName := "alerting"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 3)
+	log := vnic.Resources().Logger()
+	alerts := make(chan *l8notify.L8NotificationSet, 100)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	sla.SetArgs(&inventory.RulesConfig{
+		Rules: []*inventory.Rule{
+			{Name: "high", Condition: "myint32>10"},
+			{Name: "sustained", Condition: "myint32>10", For: 500 * time.Millisecond},
+		},
+		Notifier: inventory.NewChannelNotifier(alerts),
+		Interval: 100 * time.Millisecond,
+	})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 20}), vnic)
+	if len(service.Alerts()) != 1 || !waitFor(5*time.Second, func() bool { return len(alerts) == 1 }) {
+		log.Fail(t, "Expected the rule without a duration to raise right away, got ", len(alerts))
+		return
+	}
+	if n := <-alerts; n.ModelType != inventory.AlertModelType || n.ModelKey != "A" ||
+		n.Type != l8notify.L8NotificationType_Post || string(n.NotificationList[0].NewValue) != `"high"` {
+		log.Fail(t, "Expected a raise event of rule high for A")
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return len(alerts) == 1 }) || len(service.Alerts()) != 2 {
+		log.Fail(t, "Expected the rule with a duration to raise once it elapsed")
+		return
+	}
+	if n := <-alerts; string(n.NotificationList[0].NewValue) != `"sustained"` {
+		log.Fail(t, "Expected a raise event of rule sustained for A")
+		return
+	}
+
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	if len(service.Alerts()) != 0 || !waitFor(5*time.Second, func() bool { return len(alerts) == 2 }) {
+		log.Fail(t, "Expected both alerts to clear, got ", len(alerts))
+		return
+	}
+	for len(alerts) > 0 {
+		if n := <-alerts; n.Type != l8notify.L8NotificationType_Delete {
+			log.Fail(t, "Expected clear events")
+			return
+		}
+	}
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 20}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 2}), vnic)
+	if !waitFor(5*time.Second, func() bool { return len(alerts) == 2 }) {
+		log.Fail(t, "Expected the rule without a duration to raise and clear for B, got ", len(alerts))
+		return
+	}
+	<-alerts
+	<-alerts
+
+	replicated, err := inventory.NewTransaction().Post(&testtypes.TestProto{MyString: "C", MyInt32: 30}).Proto()
+	if err != nil {
+		log.Fail(t, err.Error())
+		return
+	}
+	replicated.Source = "another-instance"
+	service.Post(object.New(nil, replicated), vnic)
+	if len(service.Alerts()) != 1 || service.Alerts()[0].Key != "C" ||
+		!waitFor(5*time.Second, func() bool { return len(alerts) == 1 }) {
+		log.Fail(t, "Expected a replicated write to raise the rule without a duration, got ", len(alerts))
+		return
+	}
+	<-alerts
+	if !waitFor(5*time.Second, func() bool { return len(alerts) == 1 }) {
+		log.Fail(t, "Expected the rule with a duration to raise for the replicated element")
+		return
+	}
+	// B stopped matching before the duration elapsed, so its alert was never raised
+	if raised := service.Alerts(); len(raised) != 2 || raised[0].Key != "C" || raised[1].Key != "C" {
+		log.Fail(t, "Expected only the alerts of C to be raised")
+		return
+	}
+	<-alerts
+
+	if err := service.AddRule(&inventory.Rule{Name: "very-high", Condition: "myint32>25"}); err != nil {
+		log.Fail(t, "Failed to add rule ", err.Error())
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return len(alerts) == 1 }) {
+		log.Fail(t, "Expected the added rule to raise for the cached element already matching it")
+		return
+	}
+	if n := <-alerts; n.ModelKey != "C" || string(n.NotificationList[0].NewValue) != `"very-high"` {
+		log.Fail(t, "Expected a raise event of rule very-high for C")
+		return
+	}
+}
@@ -0,0 +1,128 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestSearchQueries verifies GSQL queries with a search clause: the clause may
+// stand alone or be combined with "and" before or after other conditions and
+// ahead of sort-by, limit and page, while queries combining it with "or"
+// anywhere are rejected. Prefix words only match the tokens they start.
+func TestSearchQueries(t *testing.T) {
+	serviceName := "searched"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(2, 3)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	sla.SetArgs(&inventory.SearchConfig{Fields: []string{"myString"}})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+
+	for _, name := range []string{"core switch dc1", "core router dc2", "edge switch dc1", "corelink", "access point"} {
+		service.Post(object.New(nil, &testtypes.TestProto{MyString: name}), vnic)
+	}
+
+	get := func(gsql string) (int, error) {
+		query, err := object.NewQuery(gsql, vnic.Resources())
+		if err != nil {
+			return 0, err
+		}
+		resp := service.Get(query, vnic)
+		return len(resp.Elements()), resp.Error()
+	}
+	accepted := map[string]int{
+		"select * from testproto where search('switch')":                                               2,
+		"select * from testproto where search('core*')":                                                3,
+		"select * from testproto where search('\"core switch\"')":                                      1,
+		"select * from testproto where search('switch') and mystring='edge switch dc1'":                1,
+		"select * from testproto where mystring='core switch dc1' and search('switch')":                1,
+		"select * from testproto where search('dc1') sort-by mystring":                                 2,
+		"select * from testproto where search('core*') limit 2 page 0":                                 2,
+		"select * from testproto where search('core*') and mystring=* sort-by mystring limit 2 page 1": 1,
+	}
+	for gsql, expected := range accepted {
+		if n, err := get(gsql); err != nil || n != expected {
+			log.Fail(t, "Expected ", expected, " elements for ", gsql, ", got ", n, " ", err)
+			return
+		}
+	}
+	for _, gsql := range []string{
+		"select * from testproto where search('switch') or mystring='access point'",
+		"select * from testproto where mystring='access point' or search('switch')",
+		"select * from testproto where search('switch') and mystring='core switch dc1' or mystring='access point'",
+		"select * from testproto where mystring='access point' search('switch')",
+		"select * from testproto where search('switch') and search('core')",
+	} {
+		if _, err := get(gsql); err == nil {
+			log.Fail(t, "Expected ", gsql, " to be rejected")
+			return
+		}
+	}
+}
+
+// TestSearch verifies that enabling search indexes the elements already cached and
+// follows later writes, and that phrases and prefix words match the indexed field.
+func TestSearch(t *testing.T) {
+	serviceName := "indexed"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(2, 3)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello World"}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Goodbye"}), vnic)
+	if _, _, err := center.Search("hello", nil, nil); err == nil {
+		log.Fail(t, "Expected a search before search is enabled to fail")
+		return
+	}
+	if err := center.EnableSearch("myString"); err != nil {
+		log.Fail(t, "Expected search to be enabled ", err)
+		return
+	}
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "Hello Again"}), vnic)
+	found, _, err := center.Search(`"hello again"`, nil, nil)
+	if err != nil || len(found) != 1 || found[0].(*testtypes.TestProto).MyString != "Hello Again" {
+		log.Fail(t, "Expected the phrase to match the posted element ", err)
+		return
+	}
+	found, _, err = center.Search("hel*", nil, nil)
+	if err != nil || len(found) != 2 {
+		log.Fail(t, "Expected the prefix to match both greetings ", len(found), err)
+		return
+	}
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "Hello World"}), vnic)
+	found, _, err = center.Search("hello", nil, nil)
+	if err != nil || len(found) != 1 || found[0].(*testtypes.TestProto).MyString != "Hello Again" {
+		log.Fail(t, "Expected the deleted element to leave the index ", len(found), err)
+		return
+	}
+}
@@ -0,0 +1,71 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// TestSeries verifies that every write records a sample, that samples are
+// downsampled into steps, and that a rolled back transaction removes its samples
+// and restores the oldest sample of a full ring it overwrote.
+func TestSeries(t *testing.T) {
+	serviceName := "recorded"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(2, 1)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	sla.SetArgs(&inventory.SeriesConfig{Fields: []string{"myInt32"}, Capacity: 3})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	log := vnic.Resources().Logger()
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 1}), vnic)
+	for i := int32(2); i <= 3; i++ {
+		service.Patch(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: i}), vnic)
+	}
+	samples, err := center.Series(&testtypes.TestProto{MyString: "A"}, &inventory.SeriesQuery{Field: "myInt32"})
+	if err != nil || len(samples) != 3 || samples[2].Value != 3 {
+		log.Fail(t, "Expected a sample per write ", len(samples), err)
+		return
+	}
+	samples, err = center.Series(&testtypes.TestProto{MyString: "A"},
+		&inventory.SeriesQuery{Field: "myInt32", Step: 1000 * time.Hour, Aggregate: inventory.AggregateMean})
+	if err != nil || len(samples) != 1 || samples[0].Value != 2 {
+		log.Fail(t, "Expected the samples averaged into one step ", len(samples), err)
+		return
+	}
+
+	tx := inventory.NewTransaction().Patch(&testtypes.TestProto{MyString: "A", MyInt32: 4}).
+		Patch(&testtypes.TestProto{MyString: "Missing"})
+	if _, err := center.Apply(tx, false); err == nil {
+		log.Fail(t, "Expected the transaction patching a missing key to fail")
+		return
+	}
+	samples, err = center.Series(&testtypes.TestProto{MyString: "A"}, &inventory.SeriesQuery{Field: "myInt32"})
+	if err != nil || len(samples) != 3 || samples[0].Value != 1 || samples[2].Value != 3 {
+		log.Fail(t, "Expected the rolled back sample removed and the overwritten one restored ", len(samples), err)
+		return
+	}
+}
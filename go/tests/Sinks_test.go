@@ -0,0 +1,245 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// readRecords returns the change records of a file sink file.
+func readRecords(path string) ([]*inventory.ChangeRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []*inventory.ChangeRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &inventory.ChangeRecord{}
+		if err = json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// TestFileSink verifies that the file sink appends one JSON line per element,
+// rotates the file once it exceeds the configured size dropping the oldest
+// rotated file, and keeps working after a failed rotation.
+func TestFileSink(t *testing.T) {
+	log := topo.VnicByVnetNum(1, 1).Resources().Logger()
+	path := filepath.Join(t.TempDir(), "changes.jsonl")
+	sink, err := inventory.NewFileSink(&inventory.FileSinkConfig{Path: path, MaxBytes: 200, MaxFiles: 2})
+	if err != nil {
+		log.Fail(t, "Failed to create file sink ", err.Error())
+		return
+	}
+	defer sink.Close()
+
+	elems := []interface{}{
+		&testtypes.TestProto{MyString: "a", MyInt32: 1},
+		&testtypes.TestProto{MyString: "b", MyInt32: 2},
+	}
+	if err = sink.Send(ifs.POST, elems); err != nil {
+		log.Fail(t, "Failed to send ", err.Error())
+		return
+	}
+	records, err := readRecords(path)
+	if err != nil || len(records) != 2 || records[0].Action != "POST" {
+		log.Fail(t, "Expected 2 POST lines, got ", len(records), err)
+		return
+	}
+
+	for i := 0; i < 3; i++ {
+		if err = sink.Send(ifs.PATCH, elems); err != nil {
+			log.Fail(t, "Failed to send ", err.Error())
+			return
+		}
+	}
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		records, err = readRecords(name)
+		if err != nil || len(records) != 2 || records[0].Action != "PATCH" {
+			log.Fail(t, "Expected ", name, " to hold one PATCH batch, the POST batch being dropped ", err)
+			return
+		}
+	}
+	if _, err = os.Stat(path + ".3"); !os.IsNotExist(err) {
+		log.Fail(t, "Expected at most 2 rotated files")
+		return
+	}
+
+	path = filepath.Join(t.TempDir(), "blocked.jsonl")
+	sink, err = inventory.NewFileSink(&inventory.FileSinkConfig{Path: path, MaxBytes: 200, MaxFiles: 1})
+	if err != nil {
+		log.Fail(t, "Failed to create file sink ", err.Error())
+		return
+	}
+	defer sink.Close()
+	if err = os.MkdirAll(filepath.Join(path+".1", "blocker"), 0755); err != nil {
+		log.Fail(t, "Failed to create blocking directory ", err.Error())
+		return
+	}
+	if err = sink.Send(ifs.POST, elems); err != nil {
+		log.Fail(t, "Failed to send ", err.Error())
+		return
+	}
+	if err = sink.Send(ifs.PATCH, elems); err == nil {
+		log.Fail(t, "Expected the rotation onto a directory to fail")
+		return
+	}
+	os.RemoveAll(path + ".1")
+	if err = sink.Send(ifs.PATCH, elems); err != nil {
+		log.Fail(t, "Expected the sink to keep working after a failed rotation ", err.Error())
+		return
+	}
+	if records, err = readRecords(path); err != nil || len(records) != 2 || records[0].Action != "PATCH" {
+		log.Fail(t, "Expected the retried rotation to start a new file ", err)
+		return
+	}
+}
+
+// TestWebhookSink verifies that the webhook sink signs its requests, makes a
+// single request per batch, and returns rejected batches as permanent errors.
+func TestWebhookSink(t *testing.T) {
+	log := topo.VnicByVnetNum(1, 1).Resources().Logger()
+	secret := "s3cr3t"
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		expected := "sha256=" + inventory.SignWebhook(secret, r.Header.Get(inventory.TimestampHeader), body)
+		if r.Header.Get(inventory.SignatureHeader) != expected {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		payload := &inventory.WebhookPayload{}
+		if json.Unmarshal(body, payload) != nil || payload.Action != "DELETE" || len(payload.Elements) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink, err := inventory.NewWebhookSink(&inventory.WebhookSinkConfig{URL: server.URL, Secret: secret})
+	if err != nil {
+		log.Fail(t, "Failed to create webhook sink ", err.Error())
+		return
+	}
+	defer sink.Close()
+
+	var permanent *inventory.PermanentError
+	err = sink.Send(ifs.DELETE, []interface{}{&testtypes.TestProto{MyString: "a"}})
+	if err == nil || errors.As(err, &permanent) || atomic.LoadInt32(&attempts) != 1 {
+		log.Fail(t, "Expected a single retryable failure, got attempts ", atomic.LoadInt32(&attempts), err)
+		return
+	}
+
+	err = sink.Send(ifs.DELETE, []interface{}{&testtypes.TestProto{MyString: "a"}})
+	if err != nil || atomic.LoadInt32(&attempts) != 2 {
+		log.Fail(t, "Expected the signed request to be accepted, got attempts ", atomic.LoadInt32(&attempts), err)
+		return
+	}
+
+	err = sink.Send(ifs.POST, []interface{}{&testtypes.TestProto{MyString: "a"}})
+	if !errors.As(err, &permanent) || atomic.LoadInt32(&attempts) != 3 {
+		log.Fail(t, "Expected the rejected batch to fail permanently, got attempts ", atomic.LoadInt32(&attempts), err)
+		return
+	}
+}
+
+// TestSinkRouting verifies that changes fan out to every sink, limited by the
+// actions and the filter of each sink, with deletes matched by their last cached
+// state, and that a removed sink receives nothing more.
+func TestSinkRouting(t *testing.T) {
+	serviceName := "fanned"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 4)
+	log := vnic.Resources().Logger()
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+
+	forward := &inventory.ForwardConfig{BatchSize: 1, FlushInterval: time.Hour}
+	all := utils_inventory.NewMockSink()
+	patches := utils_inventory.NewMockSink()
+	fives := utils_inventory.NewMockSink()
+	for _, cfg := range []*inventory.SinkConfig{
+		{Name: "all", Sink: all, Forward: forward},
+		{Name: "patches", Sink: patches, Forward: forward, Actions: []ifs.Action{ifs.PATCH}},
+		{Name: "fives", Sink: fives, Forward: forward, Filter: "myint32=5"},
+	} {
+		if err := service.AddSink(cfg); err != nil {
+			log.Fail(t, "Failed to add sink ", cfg.Name, " ", err.Error())
+			return
+		}
+	}
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt32: 5}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 1}), vnic)
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 2}), vnic)
+	service.Delete(object.New(nil, &testtypes.TestProto{MyString: "A"}), vnic)
+	if !waitFor(5*time.Second, func() bool { return all.Count(ifs.DELETE) == 1 }) ||
+		all.Count(ifs.POST) != 2 || all.Count(ifs.PATCH) != 1 {
+		log.Fail(t, "Expected every change to reach the unfiltered sink")
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return patches.Count(ifs.PATCH) == 1 && fives.Count(ifs.DELETE) == 1 }) {
+		log.Fail(t, "Expected the filtered sinks to receive their changes")
+		return
+	}
+	if patches.Count(ifs.POST) != 0 || patches.Count(ifs.DELETE) != 0 {
+		log.Fail(t, "Expected only patches to reach the patches sink")
+		return
+	}
+	if fives.Count(ifs.POST) != 1 || fives.Count(ifs.PATCH) != 0 {
+		log.Fail(t, "Expected only the changes of A to reach the filtered sink")
+		return
+	}
+
+	if !service.RemoveSink("patches") {
+		log.Fail(t, "Expected the patches sink to be removed")
+		return
+	}
+	service.Patch(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt32: 3}), vnic)
+	if !waitFor(5*time.Second, func() bool { return all.Count(ifs.PATCH) == 2 }) || patches.Count(ifs.PATCH) != 1 {
+		log.Fail(t, "Expected the removed sink to receive nothing more")
+		return
+	}
+}
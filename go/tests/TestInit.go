@@ -14,6 +14,8 @@
 package tests
 
 import (
+	"time"
+
 	"github.com/saichler/l8bus/go/overlay/protocol"
 	. "github.com/saichler/l8test/go/infra/t_resources"
 	. "github.com/saichler/l8test/go/infra/t_topology"
@@ -61,3 +63,17 @@ func setupTopology() {
 func shutdownTopology() {
 	topo.Shutdown()
 }
+
+// waitFor polls the condition every 10 milliseconds until it holds or the timeout
+// expires, and reports whether it held. Tests use it instead of fixed sleeps so
+// they neither flake on a slow machine nor wait longer than needed.
+func waitFor(timeout time.Duration, condition func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
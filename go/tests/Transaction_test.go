@@ -0,0 +1,160 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8inventory/go/tests/utils_inventory"
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// TestTransaction verifies that a transaction failing partway through or holding
+// an element without a key is rolled back, leaving the cache, its write statistics
+// and the sinks untouched, and that a committed one is forwarded as one unit:
+// whole to a TransactionSink, and as consecutive runs with nothing in between to
+// other sinks.
+func TestTransaction(t *testing.T) {
+	serviceName := "transacted"
+	serviceArea := byte(0)
+	vnic := topo.VnicByVnetNum(3, 2)
+	sla := ifs.NewServiceLevelAgreement(&inventory.InventoryService{}, serviceName, serviceArea, true, nil)
+	sla.SetServiceItem(&testtypes.TestProto{})
+	sla.SetServiceItemList(&testtypes.TestProtoList{})
+	sla.SetPrimaryKeys("MyString")
+	events := make(chan *l8notify.L8NotificationSet, 16)
+	sla.SetArgs(&inventory.NotifierConfig{Name: "events", Notifier: inventory.NewChannelNotifier(events)})
+	vnic.Resources().Services().Activate(sla, vnic)
+	h, _ := vnic.Resources().Services().ServiceHandler(serviceName, serviceArea)
+	service := h.(*inventory.InventoryService)
+	center := inventory.Inventory(vnic.Resources(), serviceName, serviceArea)
+	log := vnic.Resources().Logger()
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "A", MyInt64: 1}), vnic)
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "B", MyInt64: 1}), vnic)
+	for len(events) > 0 {
+		<-events
+	}
+	lastSeen := center.LastSeen(&testtypes.TestProto{MyString: "A"})
+	lastApplied := center.LastApplied()
+	var before bytes.Buffer
+	service.WriteMetrics(&before)
+	time.Sleep(10 * time.Millisecond)
+	sink := utils_inventory.NewMockSink()
+	txSink := utils_inventory.NewMockTransactionSink()
+	forward := &inventory.ForwardConfig{BatchSize: 100, FlushInterval: time.Hour}
+	service.AddSink(&inventory.SinkConfig{Name: "sink", Sink: sink, Forward: forward})
+	service.AddSink(&inventory.SinkConfig{Name: "tx", Sink: txSink, Forward: forward})
+
+	tx := inventory.NewTransaction().
+		Post(&testtypes.TestProto{MyString: "N", MyInt64: 1}).
+		Put(&testtypes.TestProto{MyString: "A", MyInt64: 2}).
+		Delete(&testtypes.TestProto{MyString: "B"}).
+		Patch(&testtypes.TestProto{MyString: "Missing", MyInt64: 3})
+	if resp := service.Transaction(tx, vnic); resp.Error() == nil {
+		log.Fail(t, "Expected the patch of a missing element to fail the transaction")
+		return
+	}
+	if center.ElementByElement(&testtypes.TestProto{MyString: "N"}) != nil {
+		log.Fail(t, "Expected the post of the failed transaction to be rolled back")
+		return
+	}
+	if elem, ok := center.ElementByElement(&testtypes.TestProto{MyString: "A"}).(*testtypes.TestProto); !ok || elem.MyInt64 != 1 {
+		log.Fail(t, "Expected the put of the failed transaction to be rolled back")
+		return
+	}
+	if center.ElementByElement(&testtypes.TestProto{MyString: "B"}) == nil {
+		log.Fail(t, "Expected the delete of the failed transaction to be rolled back")
+		return
+	}
+	missingKey := inventory.NewTransaction().
+		Delete(&testtypes.TestProto{MyString: "A"}).
+		Post(&testtypes.TestProto{MyInt64: 2})
+	if resp := service.Transaction(missingKey, vnic); resp.Error() == nil {
+		log.Fail(t, "Expected a transaction with a missing key to be rejected")
+		return
+	}
+	if center.ElementByElement(&testtypes.TestProto{MyString: "A"}) == nil {
+		log.Fail(t, "Expected the delete of the rejected transaction not to be applied")
+		return
+	}
+	var after bytes.Buffer
+	service.WriteMetrics(&after)
+	if center.LastSeen(&testtypes.TestProto{MyString: "A"}) != lastSeen || center.LastApplied() != lastApplied ||
+		mutations(before.String()) != mutations(after.String()) {
+		log.Fail(t, "Expected the failed transaction to leave the last-seen times and write statistics untouched")
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	if len(sink.Batches()) != 0 || len(txSink.Transactions()) != 0 || len(events) != 0 {
+		log.Fail(t, "Expected the failed transaction to be neither forwarded nor notified")
+		return
+	}
+
+	service.Post(object.New(nil, &testtypes.TestProto{MyString: "P"}), vnic)
+	tx.Ops = tx.Ops[:3]
+	if resp := service.Transaction(tx, vnic); resp.Error() != nil {
+		log.Fail(t, "Transaction failed ", resp.Error().Error())
+		return
+	}
+	if !waitFor(5*time.Second, func() bool { return len(txSink.Transactions()) == 1 && len(sink.Batches()) == 4 }) {
+		log.Fail(t, "Expected the transaction to be forwarded after the pending post, got ",
+			len(txSink.Transactions()), " transactions and ", len(sink.Batches()), " batches")
+		return
+	}
+	if ops := txSink.Transactions()[0].Ops; len(ops) != 3 || ops[0].Action != ifs.POST ||
+		ops[1].Action != ifs.PUT || ops[2].Action != ifs.DELETE {
+		log.Fail(t, "Expected the transaction sink to receive the three operations in order")
+		return
+	}
+	if len(txSink.Batches()) != 1 || txSink.Count(ifs.POST) != 1 {
+		log.Fail(t, "Expected the transaction sink to receive the pending post as a batch")
+		return
+	}
+	for i, batch := range sink.Batches() {
+		key := batch[0].(*testtypes.TestProto).MyString
+		if len(batch) != 1 || key != []string{"P", "N", "A", "B"}[i] {
+			log.Fail(t, "Expected the pending post and then the transaction runs in order, got ", key)
+			return
+		}
+	}
+	if !waitFor(5*time.Second, func() bool { return len(events) == 4 }) {
+		log.Fail(t, "Expected one notification per changed element, got ", len(events))
+		return
+	}
+	for _, key := range []string{"P", "N", "A", "B"} {
+		if n := <-events; n.ModelKey != key {
+			log.Fail(t, "Expected notifications in order, got ", n.ModelKey, " instead of ", key)
+			return
+		}
+	}
+}
+
+// mutations returns the write counter lines of a metrics exposition.
+func mutations(metrics string) string {
+	var lines []string
+	for _, line := range strings.Split(metrics, "\n") {
+		if strings.HasPrefix(line, "l8inventory_mutations_total") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
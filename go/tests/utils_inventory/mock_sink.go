@@ -0,0 +1,164 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_inventory
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	inventory "github.com/saichler/l8inventory/go/inv/service"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// MockSink is an in-process inventory sink used for testing fan-out of forwarded
+// changes. It counts the elements received per action, records the batches it was
+// sent and the batches reported as failed, and can be told to delay or fail sends.
+type MockSink struct {
+	// counts tracks the number of elements received per action
+	counts map[ifs.Action]int
+	// batches holds every batch received, in the order it was sent
+	batches [][]interface{}
+	// failed tracks the number of elements reported as undeliverable per action
+	failed map[ifs.Action]int
+	// failures is the number of upcoming sends to fail
+	failures int
+	// delay is how long every send takes
+	delay time.Duration
+	// inFlight is the number of sends in progress
+	inFlight int
+	// maxInFlight is the highest number of sends seen in progress at once
+	maxInFlight int
+	// mtx provides thread-safe access to the counters
+	mtx *sync.Mutex
+}
+
+// NewMockSink creates a new MockSink with empty counters.
+func NewMockSink() *MockSink {
+	return &MockSink{counts: make(map[ifs.Action]int), failed: make(map[ifs.Action]int), mtx: &sync.Mutex{}}
+}
+
+// Send records the batch received with the given action, after the configured
+// delay. Returns an error while failures are pending.
+func (this *MockSink) Send(action ifs.Action, elements []interface{}) error {
+	this.mtx.Lock()
+	this.inFlight++
+	this.maxInFlight = max(this.maxInFlight, this.inFlight)
+	delay := this.delay
+	this.mtx.Unlock()
+	time.Sleep(delay)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.inFlight--
+	if this.failures > 0 {
+		this.failures--
+		return errors.New("mock sink failure")
+	}
+	this.counts[action] += len(elements)
+	this.batches = append(this.batches, elements)
+	return nil
+}
+
+// Failed records a batch the inventory gave up forwarding.
+func (this *MockSink) Failed(action ifs.Action, elements []interface{}, err error) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.failed[action] += len(elements)
+}
+
+// Close is a no-op for the mock sink.
+func (this *MockSink) Close() error {
+	return nil
+}
+
+// Count returns the number of elements received with the given action.
+func (this *MockSink) Count(action ifs.Action) int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.counts[action]
+}
+
+// Batches returns the batches received so far.
+func (this *MockSink) Batches() [][]interface{} {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return append([][]interface{}(nil), this.batches...)
+}
+
+// FailedCount returns the number of elements with the given action reported as
+// undeliverable.
+func (this *MockSink) FailedCount(action ifs.Action) int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.failed[action]
+}
+
+// MaxInFlight returns the highest number of sends seen in progress at once.
+func (this *MockSink) MaxInFlight() int {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.maxInFlight
+}
+
+// SetDelay makes every following send take the given time.
+func (this *MockSink) SetDelay(delay time.Duration) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.delay = delay
+}
+
+// FailNext makes the next n sends return an error.
+func (this *MockSink) FailNext(n int) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.failures = n
+}
+
+// Reset clears the recorded batches and counters.
+func (this *MockSink) Reset() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.counts = make(map[ifs.Action]int)
+	this.failed = make(map[ifs.Action]int)
+	this.batches = nil
+	this.maxInFlight = 0
+}
+
+// MockTransactionSink is a MockSink that also receives transactions as one unit,
+// recording them apart from the batches.
+type MockTransactionSink struct {
+	*MockSink
+	// transactions holds every transaction received, in the order it was sent
+	transactions []*inventory.Transaction
+}
+
+// NewMockTransactionSink creates a new MockTransactionSink with empty counters.
+func NewMockTransactionSink() *MockTransactionSink {
+	return &MockTransactionSink{MockSink: NewMockSink()}
+}
+
+// SendTransaction records the transaction.
+func (this *MockTransactionSink) SendTransaction(tx *inventory.Transaction) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.transactions = append(this.transactions, tx)
+	return nil
+}
+
+// Transactions returns the transactions received so far.
+func (this *MockTransactionSink) Transactions() []*inventory.Transaction {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return append([]*inventory.Transaction(nil), this.transactions...)
+}
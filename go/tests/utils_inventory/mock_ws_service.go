@@ -0,0 +1,114 @@
+// © 2025 Sharon Aicler (saichler@gmail.com)
+//
+// Layer 8 Ecosystem is licensed under the Apache License, Version 2.0.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils_inventory
+
+import (
+	"sync"
+
+	"github.com/saichler/l8srlz/go/serialize/object"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8notify"
+)
+
+// MockWsService is a mock of the WebSocket notification service. It records the
+// notification sets multicast by inventory services, allowing tests to verify
+// their content.
+type MockWsService struct {
+	// notifications holds the received notification sets in arrival order
+	notifications []*l8notify.L8NotificationSet
+	// mtx provides thread-safe access to the notifications
+	mtx *sync.Mutex
+}
+
+// Activate registers the notification set type and initializes the mutex.
+func (this *MockWsService) Activate(sla *ifs.ServiceLevelAgreement, nic ifs.IVNic) error {
+	nic.Resources().Registry().Register(&l8notify.L8NotificationSet{})
+	this.mtx = &sync.Mutex{}
+	return nil
+}
+
+// DeActivate cleans up mock service resources. Currently a no-op.
+func (this *MockWsService) DeActivate() error {
+	return nil
+}
+
+// record stores the notification sets carried by the elements.
+func (this *MockWsService) record(pb ifs.IElements) ifs.IElements {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for _, elem := range pb.Elements() {
+		if n, ok := elem.(*l8notify.L8NotificationSet); ok {
+			this.notifications = append(this.notifications, n)
+		}
+	}
+	return object.New(nil, nil)
+}
+
+// Last returns the most recent notification set received for the given model key,
+// or nil if none was received.
+func (this *MockWsService) Last(modelKey string) *l8notify.L8NotificationSet {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for i := len(this.notifications) - 1; i >= 0; i-- {
+		if this.notifications[i].ModelKey == modelKey {
+			return this.notifications[i]
+		}
+	}
+	return nil
+}
+
+// Post records POST notifications.
+func (this *MockWsService) Post(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.record(pb)
+}
+
+// Put records PUT notifications.
+func (this *MockWsService) Put(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.record(pb)
+}
+
+// Patch records PATCH notifications.
+func (this *MockWsService) Patch(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.record(pb)
+}
+
+// Delete records DELETE notifications.
+func (this *MockWsService) Delete(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return this.record(pb)
+}
+
+// Get handles GET requests. Currently returns nil as it's not implemented.
+func (this *MockWsService) Get(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return nil
+}
+
+// GetCopy handles copy requests. Currently returns nil as it's not implemented.
+func (this *MockWsService) GetCopy(pb ifs.IElements, vnic ifs.IVNic) ifs.IElements {
+	return nil
+}
+
+// Failed handles failure notifications. Currently returns nil as it's not implemented.
+func (this *MockWsService) Failed(pb ifs.IElements, vnic ifs.IVNic, msg *ifs.Message) ifs.IElements {
+	return nil
+}
+
+// TransactionConfig returns nil as the mock service doesn't support transactions.
+func (this *MockWsService) TransactionConfig() ifs.ITransactionConfig {
+	return nil
+}
+
+// WebService returns nil as the mock service doesn't expose a web interface.
+func (this *MockWsService) WebService() ifs.IWebService {
+	return nil
+}
@@ -0,0 +1,456 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: inventory.proto
+
+package l8inventory
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// L8InventoryOp is a single operation of an inventory transaction.
+type L8InventoryOp struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// action is the ifs.Action applied: POST, PUT, PATCH or DELETE
+	Action int32 `protobuf:"varint,1,opt,name=action,proto3" json:"action,omitempty"`
+	// element is the inventory item the action is applied to
+	Element       *anypb.Any `protobuf:"bytes,2,opt,name=element,proto3" json:"element,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryOp) Reset() {
+	*x = L8InventoryOp{}
+	mi := &file_inventory_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryOp) ProtoMessage() {}
+
+func (x *L8InventoryOp) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryOp.ProtoReflect.Descriptor instead.
+func (*L8InventoryOp) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *L8InventoryOp) GetAction() int32 {
+	if x != nil {
+		return x.Action
+	}
+	return 0
+}
+
+func (x *L8InventoryOp) GetElement() *anypb.Any {
+	if x != nil {
+		return x.Element
+	}
+	return nil
+}
+
+// L8InventoryTransaction is an ordered set of operations an inventory applies
+// all-or-nothing when it receives it in a POST request.
+type L8InventoryTransaction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ops are applied in order
+	Ops []*L8InventoryOp `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
+	// source is empty in requests. It is set to the uuid of the node that applied
+	// the transaction on the copy it replicates to the other instances of the
+	// inventory, which apply it to their replica only.
+	Source        string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryTransaction) Reset() {
+	*x = L8InventoryTransaction{}
+	mi := &file_inventory_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryTransaction) ProtoMessage() {}
+
+func (x *L8InventoryTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryTransaction.ProtoReflect.Descriptor instead.
+func (*L8InventoryTransaction) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *L8InventoryTransaction) GetOps() []*L8InventoryOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+func (x *L8InventoryTransaction) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+// L8InventoryMetrics requests the metrics of an inventory in a GET request.
+type L8InventoryMetrics struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// text is empty in requests. In responses it holds the metrics of the node
+	// that served the request in the Prometheus text format.
+	Text          string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryMetrics) Reset() {
+	*x = L8InventoryMetrics{}
+	mi := &file_inventory_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryMetrics) ProtoMessage() {}
+
+func (x *L8InventoryMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryMetrics.ProtoReflect.Descriptor instead.
+func (*L8InventoryMetrics) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *L8InventoryMetrics) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// L8InventoryFacet holds the counts of a metadata function, or of one of its
+// buckets.
+type L8InventoryFacet struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is the metadata name, or the bucket name within its parent
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// count is the number of elements in the bucket. For a metadata, it is the
+	// number of elements the function returned buckets for.
+	Count int64 `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	// sum is the total of the values of the bucket's elements
+	Sum float64 `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	// average is sum divided by count, 0 if count is 0
+	Average float64 `protobuf:"fixed64,4,opt,name=average,proto3" json:"average,omitempty"`
+	// buckets are the named buckets within this one, ordered by name
+	Buckets       []*L8InventoryFacet `protobuf:"bytes,5,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryFacet) Reset() {
+	*x = L8InventoryFacet{}
+	mi := &file_inventory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryFacet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryFacet) ProtoMessage() {}
+
+func (x *L8InventoryFacet) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryFacet.ProtoReflect.Descriptor instead.
+func (*L8InventoryFacet) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *L8InventoryFacet) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *L8InventoryFacet) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *L8InventoryFacet) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *L8InventoryFacet) GetAverage() float64 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *L8InventoryFacet) GetBuckets() []*L8InventoryFacet {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+// L8InventoryFacets requests the metadata counts of an inventory in a GET
+// request.
+type L8InventoryFacets struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// query is the GSQL query selecting the elements counted, empty for every
+	// element
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// metadata is empty in requests. In responses it holds the counts of every
+	// metadata function, ordered by name.
+	Metadata      []*L8InventoryFacet `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryFacets) Reset() {
+	*x = L8InventoryFacets{}
+	mi := &file_inventory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryFacets) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryFacets) ProtoMessage() {}
+
+func (x *L8InventoryFacets) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryFacets.ProtoReflect.Descriptor instead.
+func (*L8InventoryFacets) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *L8InventoryFacets) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *L8InventoryFacets) GetMetadata() []*L8InventoryFacet {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// L8InventoryOpenAPI requests the OpenAPI document of an inventory in a GET
+// request.
+type L8InventoryOpenAPI struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// document is empty in requests. In responses it holds the OpenAPI 3 document
+	// of the inventory in JSON format.
+	Document      string `protobuf:"bytes,1,opt,name=document,proto3" json:"document,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *L8InventoryOpenAPI) Reset() {
+	*x = L8InventoryOpenAPI{}
+	mi := &file_inventory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *L8InventoryOpenAPI) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*L8InventoryOpenAPI) ProtoMessage() {}
+
+func (x *L8InventoryOpenAPI) ProtoReflect() protoreflect.Message {
+	mi := &file_inventory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use L8InventoryOpenAPI.ProtoReflect.Descriptor instead.
+func (*L8InventoryOpenAPI) Descriptor() ([]byte, []int) {
+	return file_inventory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *L8InventoryOpenAPI) GetDocument() string {
+	if x != nil {
+		return x.Document
+	}
+	return ""
+}
+
+var File_inventory_proto protoreflect.FileDescriptor
+
+const file_inventory_proto_rawDesc = "" +
+	"\n" +
+	"\x0finventory.proto\x12\vl8inventory\x1a\x19google/protobuf/any.proto\"W\n" +
+	"\rL8InventoryOp\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\x05R\x06action\x12.\n" +
+	"\aelement\x18\x02 \x01(\v2\x14.google.protobuf.AnyR\aelement\"^\n" +
+	"\x16L8InventoryTransaction\x12,\n" +
+	"\x03ops\x18\x01 \x03(\v2\x1a.l8inventory.L8InventoryOpR\x03ops\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\"(\n" +
+	"\x12L8InventoryMetrics\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"\xa1\x01\n" +
+	"\x10L8InventoryFacet\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x03R\x05count\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x18\n" +
+	"\aaverage\x18\x04 \x01(\x01R\aaverage\x127\n" +
+	"\abuckets\x18\x05 \x03(\v2\x1d.l8inventory.L8InventoryFacetR\abuckets\"d\n" +
+	"\x11L8InventoryFacets\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x129\n" +
+	"\bmetadata\x18\x02 \x03(\v2\x1d.l8inventory.L8InventoryFacetR\bmetadata\"0\n" +
+	"\x12L8InventoryOpenAPI\x12\x1a\n" +
+	"\bdocument\x18\x01 \x01(\tR\bdocumentB6Z4github.com/saichler/l8inventory/go/types/l8inventoryb\x06proto3"
+
+var (
+	file_inventory_proto_rawDescOnce sync.Once
+	file_inventory_proto_rawDescData []byte
+)
+
+func file_inventory_proto_rawDescGZIP() []byte {
+	file_inventory_proto_rawDescOnce.Do(func() {
+		file_inventory_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_inventory_proto_rawDesc), len(file_inventory_proto_rawDesc)))
+	})
+	return file_inventory_proto_rawDescData
+}
+
+var file_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_inventory_proto_goTypes = []any{
+	(*L8InventoryOp)(nil),          // 0: l8inventory.L8InventoryOp
+	(*L8InventoryTransaction)(nil), // 1: l8inventory.L8InventoryTransaction
+	(*L8InventoryMetrics)(nil),     // 2: l8inventory.L8InventoryMetrics
+	(*L8InventoryFacet)(nil),       // 3: l8inventory.L8InventoryFacet
+	(*L8InventoryFacets)(nil),      // 4: l8inventory.L8InventoryFacets
+	(*L8InventoryOpenAPI)(nil),     // 5: l8inventory.L8InventoryOpenAPI
+	(*anypb.Any)(nil),              // 6: google.protobuf.Any
+}
+var file_inventory_proto_depIdxs = []int32{
+	6, // 0: l8inventory.L8InventoryOp.element:type_name -> google.protobuf.Any
+	0, // 1: l8inventory.L8InventoryTransaction.ops:type_name -> l8inventory.L8InventoryOp
+	3, // 2: l8inventory.L8InventoryFacet.buckets:type_name -> l8inventory.L8InventoryFacet
+	3, // 3: l8inventory.L8InventoryFacets.metadata:type_name -> l8inventory.L8InventoryFacet
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_inventory_proto_init() }
+func file_inventory_proto_init() {
+	if File_inventory_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_inventory_proto_rawDesc), len(file_inventory_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_inventory_proto_goTypes,
+		DependencyIndexes: file_inventory_proto_depIdxs,
+		MessageInfos:      file_inventory_proto_msgTypes,
+	}.Build()
+	File_inventory_proto = out.File
+	file_inventory_proto_goTypes = nil
+	file_inventory_proto_depIdxs = nil
+}
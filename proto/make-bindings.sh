@@ -0,0 +1,6 @@
+#!/usr/bin/env bash
+# Generates the Go bindings of the inventory protos into go/types.
+# Requires protoc and protoc-gen-go on the PATH.
+set -e
+cd "$(dirname "$0")"
+protoc --go_out=../go --go_opt=module=github.com/saichler/l8inventory/go inventory.proto